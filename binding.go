@@ -0,0 +1,222 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"slices"
+	"strings"
+)
+
+// Binding is a callback registered on a Hook
+type Binding struct {
+	hook *Hook
+	when Kind
+	// codes holds the keys, buttons the mouse buttons of mouse kinds
+	codes   combination
+	buttons combination
+	cb      func(Event)
+
+	match   MatchMode
+	ignored Modifiers
+	// step and acc add up the rotation of MouseWheel bindings
+	step int32
+	acc  int32
+	// seq is set for key sequences registered with RegisterSequence
+	seq *sequence
+	// gesture is set for bindings registered with RegisterGesture
+	gesture *gesture
+}
+
+// Kind returns the event kind the binding is registered for
+func (b *Binding) Kind() Kind {
+	b.hook.stateMu.Lock()
+	defer b.hook.stateMu.Unlock()
+
+	return b.when
+}
+
+// Unregister removes the binding from its Hook,
+// it is safe to call while Process is running
+func (b *Binding) Unregister() {
+	if b == nil {
+		return
+	}
+
+	b.hook.stateMu.Lock()
+	b.hook.remove(b)
+	b.hook.stateMu.Unlock()
+}
+
+// ReplaceBinding changes the keys and callback of b in place,
+// a nil cb keeps the current callback. The match mode of b is kept,
+// a key sequence or gesture replaced this way becomes a plain
+// combination binding.
+//
+// If one of the keys is unknown b is left untouched.
+// It is safe to call while Process is running.
+func (h *Hook) ReplaceBinding(b *Binding, when Kind, cmds []string, cb func(Event)) error {
+	if b == nil || b.hook != h {
+		return ErrForeignBinding
+	}
+
+	codes, buttons, err := h.codes(when, cmds)
+	if err != nil {
+		return err
+	}
+
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	h.remove(b)
+	b.when = when
+	b.codes = codes
+	b.buttons = buttons
+	b.acc = 0
+	b.seq = nil
+	b.gesture = nil
+	if cb != nil {
+		b.cb = cb
+	}
+	h.add(b)
+
+	return nil
+}
+
+// add stores b, replacing the binding of the same keys,
+// the caller must hold stateMu
+func (h *Hook) add(b *Binding) {
+	if b.seq != nil {
+		h.sequences[b] = b.seq
+		return
+	}
+
+	if b.gesture != nil {
+		h.gestures[b] = b.gesture
+		return
+	}
+
+	if _, ok := h.registry[b.when]; !ok {
+		h.registry[b.when] = make(map[string]*Binding)
+		h.index[b.when] = make(map[Code]map[*Binding]bool)
+	}
+
+	if old, ok := h.registry[b.when][b.key()]; ok {
+		h.remove(old)
+	}
+	h.registry[b.when][b.key()] = b
+
+	for _, code := range b.triggers() {
+		if _, ok := h.index[b.when][code]; !ok {
+			h.index[b.when][code] = make(map[*Binding]bool)
+		}
+		h.index[b.when][code][b] = true
+	}
+}
+
+// remove deletes b unless it has been replaced by another binding,
+// the caller must hold stateMu
+func (h *Hook) remove(b *Binding) {
+	if b.seq != nil {
+		b.seq.reset()
+		delete(h.sequences, b)
+		return
+	}
+
+	if b.gesture != nil {
+		b.gesture.stop()
+		delete(h.gestures, b)
+		return
+	}
+
+	if h.registry[b.when][b.key()] != b {
+		return
+	}
+
+	delete(h.registry[b.when], b.key())
+	for _, code := range b.triggers() {
+		delete(h.index[b.when][code], b)
+		if len(h.index[b.when][code]) == 0 {
+			delete(h.index[b.when], code)
+		}
+	}
+}
+
+// key returns a string identifying the keys and buttons of b in the registry
+func (b *Binding) key() string {
+	if len(b.buttons) == 0 {
+		return b.codes.key()
+	}
+	return string(rune(len(b.codes))) + b.codes.key() + b.buttons.key()
+}
+
+// triggers returns the codes whose events b is checked on,
+// the buttons of mouse bindings and the keys of all others
+func (b *Binding) triggers() combination {
+	if len(b.buttons) != 0 {
+		return b.buttons
+	}
+	return b.codes
+}
+
+// keyBindings returns the combination bindings of when that have code
+// as a member, a side specific modifier like left_control finds the
+// bindings of its generic key as well, the caller must hold stateMu
+func (h *Hook) keyBindings(when Kind, code Code) []*Binding {
+	bindings := []*Binding{}
+	for b := range h.index[when][code] {
+		bindings = append(bindings, b)
+	}
+
+	if generic, ok := genericKey(code); ok {
+		for b := range h.index[when][generic] {
+			if !h.index[when][code][b] {
+				bindings = append(bindings, b)
+			}
+		}
+	}
+
+	return bindings
+}
+
+// combination is a set of key codes, sorted and without duplicates
+type combination []Code
+
+func newCombination(codes []Code) combination {
+	c := slices.Clone(codes)
+	slices.Sort(c)
+	return slices.Compact(c)
+}
+
+// key returns a string identifying the combination in a map
+func (c combination) key() string {
+	var sb strings.Builder
+	for _, code := range c {
+		sb.WriteByte(byte(code >> 8))
+		sb.WriteByte(byte(code))
+	}
+	return sb.String()
+}
+
+func (c combination) contains(code Code) bool {
+	_, ok := slices.BinarySearch(c, code)
+	return ok
+}
+
+// hasKey reports whether a key event of code belongs to c,
+// a side specific modifier belongs to its generic key as well
+func (c combination) hasKey(code Code) bool {
+	if c.contains(code) {
+		return true
+	}
+
+	generic, ok := genericKey(code)
+	return ok && c.contains(generic)
+}
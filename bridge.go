@@ -0,0 +1,130 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import "context"
+
+// rawEvent is an event as the C hook hands it to Go,
+// it has to match go_event in event/bridge.h field by field
+type rawEvent struct {
+	Time      uint64
+	Rotation  int32
+	Keychar   uint32
+	Mask      uint16
+	Reserved  uint16
+	Keycode   uint16
+	Rawcode   uint16
+	Button    uint16
+	Clicks    uint16
+	Amount    uint16
+	X         int16
+	Y         int16
+	Type      uint8
+	WheelType uint8
+	Direction uint8
+	Synthetic uint8
+	_         [2]uint8
+}
+
+// awaitEnabled waits for the hook run in the background to be enabled,
+// status receives what it returned with
+//
+// If ctx is done first, stop is called once the hook is enabled and
+// awaitEnabled waits for it to return. Stopping the hook any earlier
+// is ignored by libuiohook and would leave it running.
+func awaitEnabled(ctx context.Context, enabled <-chan struct{}, status <-chan int, stop func()) error {
+	select {
+	case <-enabled:
+		return nil
+	case st := <-status:
+		return startStatusError(st)
+	case <-ctx.Done():
+	}
+
+	select {
+	case <-enabled:
+		stop()
+		<-status
+	case <-status:
+	}
+	return ctx.Err()
+}
+
+// startStatusError is the error of a hook that returned with st
+// before it was enabled
+func startStatusError(st int) error {
+	if err := statusError(st); err != nil {
+		return err
+	}
+	return ErrHookFailed
+}
+
+// deliverBatch is the number of events taken from the C hook at once
+const deliverBatch = 64
+
+// deliver drains the events of take each time wake fires,
+// until stop is closed
+func deliver(stop, wake <-chan struct{}, take func([]rawEvent) int, dispatch func(Event)) {
+	buf := make([]rawEvent, deliverBatch)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-wake:
+			drain(buf, take, dispatch)
+		}
+	}
+}
+
+// drain dispatches the events of take until none are left
+func drain(buf []rawEvent, take func([]rawEvent) int, dispatch func(Event)) {
+	for {
+		n := take(buf)
+		for i := range buf[:n] {
+			dispatch(decodeEvent(&buf[i]))
+		}
+
+		if n < len(buf) {
+			return
+		}
+	}
+}
+
+// decodeEvent converts an event of the C hook to an Event
+func decodeEvent(r *rawEvent) Event {
+	out := Event{
+		Kind:       Kind(r.Type),
+		NativeTime: r.Time,
+		Mask:       r.Mask,
+		Reserved:   r.Reserved,
+		Keycode:    r.Keycode,
+		Rawcode:    r.Rawcode,
+		Keychar:    rune(r.Keychar),
+		Button:     r.Button,
+		Clicks:     r.Clicks,
+		X:          r.X,
+		Y:          r.Y,
+		WheelType:  WheelType(r.WheelType),
+		Amount:     r.Amount,
+		Rotation:   r.Rotation,
+		Direction:  r.Direction,
+		Synthetic:  r.Synthetic != 0,
+	}
+
+	if out.Keychar != CharUndefined {
+		lck.Lock()
+		raw2key[out.Rawcode] = string([]rune{out.Keychar})
+		lck.Unlock()
+	}
+
+	out.When = eventTimeOf(out.NativeTime)
+	return out
+}
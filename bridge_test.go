@@ -0,0 +1,137 @@
+package hook
+
+import (
+	"encoding/json"
+	"testing"
+	"unsafe"
+)
+
+// The offsets below are the ones of go_event in event/bridge.h
+func TestRawEventLayout(t *testing.T) {
+	r := rawEvent{}
+	offsets := []struct {
+		name string
+		got  uintptr
+		want uintptr
+	}{
+		{"time", unsafe.Offsetof(r.Time), 0},
+		{"rotation", unsafe.Offsetof(r.Rotation), 8},
+		{"keychar", unsafe.Offsetof(r.Keychar), 12},
+		{"mask", unsafe.Offsetof(r.Mask), 16},
+		{"x", unsafe.Offsetof(r.X), 30},
+		{"type", unsafe.Offsetof(r.Type), 34},
+		{"direction", unsafe.Offsetof(r.Direction), 36},
+		{"synthetic", unsafe.Offsetof(r.Synthetic), 37},
+		{"size", unsafe.Sizeof(r), 40},
+	}
+
+	for _, o := range offsets {
+		if o.got != o.want {
+			t.Errorf("%s: expected offset %d, got %d", o.name, o.want, o.got)
+		}
+	}
+}
+
+func TestDecodeWheelEvent(t *testing.T) {
+	ev := decodeEvent(&rawEvent{
+		Type: MouseWheel, Time: 1700000000123, Mask: 2,
+		Clicks: 1, X: -120, Y: 340,
+		WheelType: 1, Amount: 3, Rotation: -1, Direction: 3,
+	})
+
+	w, ok := ev.Wheel()
+	if !ok {
+		t.Fatal("Expected a wheel event, got", ev)
+	}
+
+	want := WheelEvent{
+		Type:      WheelUnitScroll,
+		Direction: WheelVertical,
+		Rotation:  -1,
+		Amount:    3,
+		X:         -120,
+		Y:         340,
+		Clicks:    1,
+	}
+	if w != want {
+		t.Fatalf("Expected %+v, got %+v", want, w)
+	}
+	if w.Delta() != -3 {
+		t.Fatal("Expected a delta of -3, got", w.Delta())
+	}
+	if !ev.Modifiers().CtrlLeft() {
+		t.Fatal("Expected the left ctrl modifier, got", ev.Modifiers())
+	}
+}
+
+func TestDecodeHorizontalBlockWheelEvent(t *testing.T) {
+	ev := decodeEvent(&rawEvent{
+		Type: MouseWheel, Time: 1700000000123,
+		X: 10, Y: 20, WheelType: 2, Amount: 1, Rotation: 2, Direction: 4,
+	})
+
+	w, _ := ev.Wheel()
+	if w.Type != WheelBlockScroll || w.Direction != WheelHorizontal || w.Rotation != 2 {
+		t.Fatalf("Unexpected wheel event %+v", w)
+	}
+	if code, _ := wheelButton(ev); code != Code(MouseMap["wheelRight"]) {
+		t.Fatal("Expected wheelRight, got", code)
+	}
+}
+
+func TestDecodeKeyAndMouseEvents(t *testing.T) {
+	ev := decodeEvent(&rawEvent{
+		Type: KeyDown, Time: 1700000000123, Mask: 1,
+		Keycode: 30, Rawcode: 65, Keychar: CharUndefined,
+	})
+	if ev.Kind != KeyDown || ev.Keycode != 30 || ev.Rawcode != 65 || ev.Keychar != CharUndefined || ev.Synthetic {
+		t.Fatalf("Unexpected key event %+v", ev)
+	}
+	if _, ok := ev.Wheel(); ok {
+		t.Fatal("Expected no wheel fields for a key event")
+	}
+
+	ev = decodeEvent(&rawEvent{
+		Type: MouseDown, Time: 1700000000123, Mask: 256,
+		X: -5, Y: 600, Button: 1, Clicks: 2, Synthetic: 1,
+	})
+	if ev.Kind != MouseDown || ev.X != -5 || ev.Y != 600 || ev.Button != 1 || ev.Clicks != 2 || !ev.Synthetic {
+		t.Fatalf("Unexpected mouse event %+v", ev)
+	}
+	if ev.NativeTime != 1700000000123 || ev.When.IsZero() {
+		t.Fatal("Expected the native time to be kept, got", ev.NativeTime, ev.When)
+	}
+}
+
+// jsonEvent is an event in the JSON format dispatch_proc used
+// to send before the binary bridge, kept as a baseline
+const jsonEvent = `{"id":4,"time":1700000000123,"mask":1,"reserved":0,"keycode":30,"rawcode":65,"keychar":65535}`
+
+func reportEventsPerSecond(b *testing.B) {
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "events/s")
+}
+
+func BenchmarkDecodeJSON(b *testing.B) {
+	b.ReportAllocs()
+	data := []byte(jsonEvent)
+	for i := 0; i < b.N; i++ {
+		ev := Event{}
+		if err := json.Unmarshal(data, &ev); err != nil {
+			b.Fatal(err)
+		}
+		ev.When = eventTimeOf(ev.NativeTime)
+	}
+	reportEventsPerSecond(b)
+}
+
+func BenchmarkDecodeRaw(b *testing.B) {
+	b.ReportAllocs()
+	raw := rawEvent{
+		Type: KeyDown, Time: 1700000000123, Mask: 1,
+		Keycode: 30, Rawcode: 65, Keychar: CharUndefined,
+	}
+	for i := 0; i < b.N; i++ {
+		_ = decodeEvent(&raw)
+	}
+	reportEventsPerSecond(b)
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"sync"
+	"time"
+)
+
+// eventTimeOf converts the native timestamp of an event to a time.Time,
+// events without one happened now
+func eventTimeOf(raw uint64) time.Time {
+	now := time.Now()
+	if raw == 0 {
+		return now
+	}
+
+	if age, ok := nativeAge(raw); ok {
+		return now.Add(-age)
+	}
+	return defaultClock.at(raw, now)
+}
+
+// recalibrateAfter is how far a clock offset may grow
+// before the native clock is assumed to have restarted
+const recalibrateAfter = time.Hour
+
+// calibratedClock converts the timestamps of a native clock that can
+// not be read directly, like the X server time, to wall clock time
+//
+// Every event was taken at least its latency before it arrives, so
+// the smallest difference seen between its arrival and its timestamp
+// is the closest guess of the offset of the two clocks.
+type calibratedClock struct {
+	mu sync.Mutex
+	// unit is the duration of one tick of the native clock
+	unit   time.Duration
+	offset time.Time
+}
+
+// at returns the wall clock time of raw, arriving at now
+func (c *calibratedClock) at(raw uint64, now time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	native := time.Duration(raw) * c.unit
+	offset := now.Add(-native)
+	if c.offset.IsZero() || offset.Before(c.offset) || offset.Sub(c.offset) > recalibrateAfter {
+		c.offset = offset
+	}
+
+	return c.offset.Add(native)
+}
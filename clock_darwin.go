@@ -0,0 +1,40 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+//go:build cgo && !nocgo
+
+package hook
+
+/*
+#include <mach/mach_time.h>
+*/
+import "C"
+
+import "time"
+
+// The native time on macOS is mach_absolute_time, which
+// CGEventGetTimestamp shares, in ticks of the mach timebase
+var timebase = func() (t C.mach_timebase_info_data_t) {
+	C.mach_timebase_info(&t)
+	return
+}()
+
+var defaultClock = &calibratedClock{unit: time.Nanosecond}
+
+// nativeAge returns how long ago the native time raw was taken
+func nativeAge(raw uint64) (time.Duration, bool) {
+	now := uint64(C.mach_absolute_time())
+	if now < raw {
+		return 0, true
+	}
+
+	ticks := now - raw
+	return time.Duration(ticks * uint64(timebase.numer) / uint64(timebase.denom)), true
+}
@@ -0,0 +1,25 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+//go:build !windows && !(darwin && cgo && !nocgo)
+
+package hook
+
+import "time"
+
+// The native time on X11 is the X server time in milliseconds,
+// which can not be read without a round trip to the server
+var defaultClock = &calibratedClock{unit: time.Millisecond}
+
+// nativeAge can not tell the age of X server timestamps,
+// they go through defaultClock instead
+func nativeAge(raw uint64) (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,40 @@
+package hook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalibratedClock(t *testing.T) {
+	c := &calibratedClock{unit: time.Millisecond}
+	t0 := time.Now()
+
+	cases := []struct {
+		raw     uint64
+		arrival time.Duration
+		want    time.Duration
+	}{
+		{1000, 5 * time.Millisecond, 5 * time.Millisecond},
+		// less latency moves the offset
+		{1100, 102 * time.Millisecond, 102 * time.Millisecond},
+		// more latency keeps it
+		{1200, 250 * time.Millisecond, 202 * time.Millisecond},
+		// the native clock restarted
+		{10, 2 * time.Hour, 2 * time.Hour},
+		{20, 2*time.Hour + 15*time.Millisecond, 2*time.Hour + 10*time.Millisecond},
+	}
+
+	for _, tc := range cases {
+		got := c.at(tc.raw, t0.Add(tc.arrival))
+		if got.Sub(t0) != tc.want {
+			t.Errorf("%v at %v: expected %v, got %v", tc.raw, tc.arrival, tc.want, got.Sub(t0))
+		}
+	}
+}
+
+func TestEventTimeWithoutNativeTime(t *testing.T) {
+	before := time.Now()
+	if when := eventTimeOf(0); when.Before(before) || when.After(time.Now()) {
+		t.Fatal("Expected the current time, got", when)
+	}
+}
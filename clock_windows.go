@@ -0,0 +1,29 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"syscall"
+	"time"
+)
+
+// The native time on windows is the GetTickCount clock, the 32 bit
+// milliseconds since boot of GetMessageTime and MSLLHOOKSTRUCT
+var getTickCount = syscall.NewLazyDLL("kernel32.dll").NewProc("GetTickCount")
+
+var defaultClock = &calibratedClock{unit: time.Millisecond}
+
+// nativeAge returns how long ago the native time raw was taken
+func nativeAge(raw uint64) (time.Duration, bool) {
+	now, _, _ := getTickCount.Call()
+	// the tick count wraps after 49.7 days
+	return time.Duration(uint32(now)-uint32(raw)) * time.Millisecond, true
+}
@@ -0,0 +1,207 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRing stands in for the ring of event/bridge.h
+type fakeRing struct {
+	mu     sync.Mutex
+	events []rawEvent
+	// idle counts the takes that found nothing
+	idle int
+}
+
+func (r *fakeRing) push(ev rawEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, ev)
+	r.mu.Unlock()
+}
+
+func (r *fakeRing) take(buf []rawEvent) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := copy(buf, r.events)
+	r.events = r.events[n:]
+	if n == 0 {
+		r.idle++
+	}
+	return n
+}
+
+func notify(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	ring := &fakeRing{}
+	wake := make(chan struct{}, 1)
+	stop := make(chan struct{})
+	got := make(chan Event, 2*deliverBatch)
+
+	done := make(chan struct{})
+	go func() {
+		deliver(stop, wake, ring.take, func(e Event) { got <- e })
+		close(done)
+	}()
+
+	// more than a batch in one wake up
+	for i := 0; i < deliverBatch+3; i++ {
+		ring.push(rawEvent{Type: KeyDown, Rawcode: uint16(i)})
+	}
+	notify(wake)
+
+	for i := 0; i < deliverBatch+3; i++ {
+		select {
+		case e := <-got:
+			if e.Rawcode != uint16(i) {
+				t.Fatalf("Expected event %d, got %d", i, e.Rawcode)
+			}
+		case <-time.After(TIMEOUT):
+			t.Fatal("Timeout waiting for event", i)
+		}
+	}
+
+	close(stop)
+	<-done
+}
+
+func TestAwaitEnabledCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	enabled := make(chan struct{})
+	status := make(chan int, 1)
+
+	stopped := make(chan struct{})
+	stop := func() {
+		select {
+		case <-enabled:
+		default:
+			t.Error("Expected the hook to be stopped only once it is enabled")
+		}
+		close(stopped)
+		status <- statusSuccess
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- awaitEnabled(ctx, enabled, status, stop)
+	}()
+
+	// cancelled right after the start, before the hook is enabled
+	cancel()
+	select {
+	case err := <-result:
+		t.Fatal("Expected to wait for the hook to be enabled, got", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(enabled)
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatal("Expected context.Canceled, got", err)
+		}
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for awaitEnabled")
+	}
+	<-stopped
+}
+
+func TestAwaitEnabledFailed(t *testing.T) {
+	status := make(chan int, 1)
+	status <- statusXOpenDisplay
+
+	err := awaitEnabled(context.Background(), make(chan struct{}), status, func() {
+		t.Error("Expected no stop for a hook that failed")
+	})
+	if !errors.Is(err, ErrDisplayUnavailable) {
+		t.Fatal("Expected ErrDisplayUnavailable, got", err)
+	}
+
+	status <- statusSuccess
+	err = awaitEnabled(context.Background(), make(chan struct{}), status, func() {})
+	if !errors.Is(err, ErrHookFailed) {
+		t.Fatal("Expected ErrHookFailed for a hook never enabled, got", err)
+	}
+}
+
+// idlePeriod is how long benchmarkDelivery counts idle wake ups
+const idlePeriod = 100 * time.Millisecond
+
+// benchmarkDelivery measures the time from buffering an event to its
+// dispatch, either woken up for every event or by the 10ms ticker the
+// events used to be polled with. Every event carries its index as
+// native time.
+func benchmarkDelivery(b *testing.B, polling bool) {
+	ring := &fakeRing{}
+	wake := make(chan struct{}, 1)
+	stop := make(chan struct{})
+	delivered := make(chan struct{})
+
+	sentAt := make([]time.Time, b.N)
+	latencies := make([]time.Duration, 0, b.N)
+	done := make(chan struct{})
+	go func() {
+		deliver(stop, wake, ring.take, func(e Event) {
+			latencies = append(latencies, time.Since(sentAt[e.NativeTime]))
+			delivered <- struct{}{}
+		})
+		close(done)
+	}()
+
+	if polling {
+		go func() {
+			ticker := time.NewTicker(10 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					notify(wake)
+				}
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sentAt[i] = time.Now()
+		ring.push(rawEvent{Type: KeyDown, Time: uint64(i)})
+		if !polling {
+			notify(wake)
+		}
+		<-delivered
+	}
+	b.StopTimer()
+
+	// count the wake ups without any event
+	ring.mu.Lock()
+	ring.idle = 0
+	ring.mu.Unlock()
+	time.Sleep(idlePeriod)
+	close(stop)
+	<-done
+
+	slices.Sort(latencies)
+	b.ReportMetric(float64(latencies[len(latencies)/2].Nanoseconds()), "p50-ns")
+	b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns")
+	b.ReportMetric(float64(ring.idle)/idlePeriod.Seconds(), "idle-wakeups/s")
+}
+
+func BenchmarkDeliveryPolling(b *testing.B) {
+	benchmarkDelivery(b, true)
+}
+
+func BenchmarkDeliveryNotify(b *testing.B) {
+	benchmarkDelivery(b, false)
+}
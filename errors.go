@@ -0,0 +1,218 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Errors reported when the native hook can not be started,
+// use errors.Is to check for them
+var (
+	ErrHookFailed          = errors.New("hook: native hook failed")
+	ErrOutOfMemory         = errors.New("hook: failed to allocate memory")
+	ErrDisplayUnavailable  = errors.New("hook: failed to open X11 display")
+	ErrXRecordMissing      = errors.New("hook: unable to locate XRecord extension")
+	ErrXRecordContext      = errors.New("hook: unable to set up XRecord context")
+	ErrWindowsHook         = errors.New("hook: failed to register low level windows hook")
+	ErrAccessibilityDenied = errors.New("hook: failed to enable access for assistive devices")
+	ErrRunLoop             = errors.New("hook: failed to set up apple run loop")
+)
+
+// ErrBackendUnavailable is returned when starting the native hook
+// of a build without cgo or with the nocgo tag, and when posting
+// while an evdev or X11 source is started
+var ErrBackendUnavailable = errors.New("hook: native backend unavailable")
+
+// ErrForeignBinding is returned when a Binding is used
+// with a Hook it was not registered on
+var ErrForeignBinding = errors.New("hook: binding belongs to another hook")
+
+// ErrHookRunning is returned by SetSource while the hook is started
+var ErrHookRunning = errors.New("hook: hook is running")
+
+// ErrSourceStopped is returned by the FakeSource helpers
+// when the source is not started
+var ErrSourceStopped = errors.New("hook: source is not started")
+
+// UnknownKeyError is returned by Register for a key
+// or mouse button name that does not exist
+type UnknownKeyError struct {
+	Name string
+	// Button is true if Name was looked up as a mouse button
+	Button bool
+	// Suggestions holds the known names closest to Name
+	Suggestions []string
+}
+
+func (e *UnknownKeyError) Error() string {
+	what := "key"
+	if e.Button {
+		what = "mouse button"
+	}
+
+	msg := fmt.Sprintf("hook: unknown %s %q", what, e.Name)
+	switch len(e.Suggestions) {
+	case 0:
+		return msg
+	case 1:
+		return fmt.Sprintf("%s, did you mean %q?", msg, e.Suggestions[0])
+	}
+
+	quoted := make([]string, len(e.Suggestions))
+	for i, s := range e.Suggestions {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%s, did you mean one of %s?", msg, strings.Join(quoted, ", "))
+}
+
+func unknownKey(name string, known []string) error {
+	return &UnknownKeyError{Name: name, Suggestions: suggest(name, known)}
+}
+
+func unknownButton(name string, known []string) error {
+	return &UnknownKeyError{Name: name, Button: true, Suggestions: suggest(name, known)}
+}
+
+// maxSuggestions limits the suggestions of an UnknownKeyError
+const maxSuggestions = 3
+
+// suggest returns the names of known within a small
+// edit distance of name, closest first
+func suggest(name string, known []string) []string {
+	lower := strings.ToLower(name)
+	limit := max(1, len(lower)/3)
+
+	type match struct {
+		name string
+		dist int
+	}
+	matches := []match{}
+	for _, k := range known {
+		if d := editDistance(lower, strings.ToLower(k)); d <= limit {
+			matches = append(matches, match{k, d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	out := []string{}
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		out = append(out, matches[i].name)
+	}
+	return out
+}
+
+// editDistance returns the optimal string alignment distance between
+// a and b, that is the Levenshtein distance counting a transposition
+// of two adjacent characters as a single edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// libuiohook status codes, see hook/iohook.h
+const (
+	statusSuccess = 0x00
+	statusFailure = 0x01
+
+	statusOutOfMemory = 0x02
+
+	statusXOpenDisplay         = 0x20
+	statusXRecordNotFound      = 0x21
+	statusXRecordAllocRange    = 0x22
+	statusXRecordCreateContext = 0x23
+	statusXRecordEnableContext = 0x24
+	statusXRecordGetContext    = 0x25
+
+	statusSetWindowsHookEx = 0x30
+	statusGetModuleHandle  = 0x31
+
+	statusAXAPIDisabled         = 0x40
+	statusCreateEventPort       = 0x41
+	statusCreateRunLoopSource   = 0x42
+	statusGetRunLoop            = 0x43
+	statusCreateRunLoopObserver = 0x44
+)
+
+// NativeError is a non success status returned by libuiohook
+type NativeError struct {
+	Status int
+	Err    error
+}
+
+func (e *NativeError) Error() string {
+	return fmt.Sprintf("%v (%#x)", e.Err, e.Status)
+}
+
+func (e *NativeError) Unwrap() error {
+	return e.Err
+}
+
+// statusError maps a libuiohook status code to a Go error
+func statusError(status int) error {
+	var err error
+	switch status {
+	case statusSuccess:
+		return nil
+	case statusOutOfMemory:
+		err = ErrOutOfMemory
+	case statusXOpenDisplay:
+		err = ErrDisplayUnavailable
+	case statusXRecordNotFound:
+		err = ErrXRecordMissing
+	case statusXRecordAllocRange, statusXRecordCreateContext,
+		statusXRecordEnableContext, statusXRecordGetContext:
+		err = ErrXRecordContext
+	case statusSetWindowsHookEx, statusGetModuleHandle:
+		err = ErrWindowsHook
+	case statusAXAPIDisabled:
+		err = ErrAccessibilityDenied
+	case statusCreateEventPort, statusCreateRunLoopSource,
+		statusGetRunLoop, statusCreateRunLoopObserver:
+		err = ErrRunLoop
+	default:
+		err = ErrHookFailed
+	}
+
+	return &NativeError{Status: status, Err: err}
+}
@@ -0,0 +1,445 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"encoding/binary"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// evdev event types and codes, see linux/input-event-codes.h
+const (
+	evSyn = 0x00
+	evKey = 0x01
+	evRel = 0x02
+
+	synReport  = 0x00
+	synDropped = 0x03
+
+	relX      = 0x00
+	relY      = 0x01
+	relHWheel = 0x06
+	relWheel  = 0x08
+
+	keyRepeat = 2
+)
+
+// evdevRecordSize is the size of struct input_event,
+// its struct timeval holds two longs
+const evdevRecordSize = 2*strconv.IntSize/8 + 8
+
+// evdevBuffer is the number of events an evdev or X11 source buffers
+const evdevBuffer = 64
+
+// evdevClickTime is the longest pause between two presses
+// of a button counted as a multi click
+const evdevClickTime = 500 * time.Millisecond
+
+// evdevRecord is a decoded struct input_event
+type evdevRecord struct {
+	sec, usec int64
+	typ, code uint16
+	value     int32
+}
+
+// parseEvdevRecord decodes an input_event of evdevRecordSize bytes
+func parseEvdevRecord(b []byte) evdevRecord {
+	r := evdevRecord{}
+	word := strconv.IntSize / 8
+	if word == 8 {
+		r.sec = int64(binary.NativeEndian.Uint64(b))
+		r.usec = int64(binary.NativeEndian.Uint64(b[8:]))
+	} else {
+		r.sec = int64(int32(binary.NativeEndian.Uint32(b)))
+		r.usec = int64(int32(binary.NativeEndian.Uint32(b[4:])))
+	}
+	b = b[2*word:]
+	r.typ = binary.NativeEndian.Uint16(b)
+	r.code = binary.NativeEndian.Uint16(b[2:])
+	r.value = int32(binary.NativeEndian.Uint32(b[4:]))
+	return r
+}
+
+// evdevKey is the Rawcode and the libuiohook Keycode of an evdev key
+type evdevKey struct {
+	raw, code uint16
+}
+
+// evdevKeys maps the evdev KEY_* codes to WindowsVKCodes
+// and the VC_* codes of hook/iohook.h
+var evdevKeys = map[uint16]evdevKey{
+	1:  {0x1B, 0x0001}, // KEY_ESC
+	2:  {0x31, 0x0002}, // KEY_1
+	3:  {0x32, 0x0003},
+	4:  {0x33, 0x0004},
+	5:  {0x34, 0x0005},
+	6:  {0x35, 0x0006},
+	7:  {0x36, 0x0007},
+	8:  {0x37, 0x0008},
+	9:  {0x38, 0x0009},
+	10: {0x39, 0x000A}, // KEY_9
+	11: {0x30, 0x000B}, // KEY_0
+	12: {0xBD, 0x000C}, // KEY_MINUS
+	13: {0xBB, 0x000D}, // KEY_EQUAL
+	14: {0x08, 0x000E}, // KEY_BACKSPACE
+	15: {0x09, 0x000F}, // KEY_TAB
+	16: {0x51, 0x0010}, // KEY_Q
+	17: {0x57, 0x0011}, // KEY_W
+	18: {0x45, 0x0012}, // KEY_E
+	19: {0x52, 0x0013}, // KEY_R
+	20: {0x54, 0x0014}, // KEY_T
+	21: {0x59, 0x0015}, // KEY_Y
+	22: {0x55, 0x0016}, // KEY_U
+	23: {0x49, 0x0017}, // KEY_I
+	24: {0x4F, 0x0018}, // KEY_O
+	25: {0x50, 0x0019}, // KEY_P
+	26: {0xDB, 0x001A}, // KEY_LEFTBRACE
+	27: {0xDD, 0x001B}, // KEY_RIGHTBRACE
+	28: {0x0D, 0x001C}, // KEY_ENTER
+	29: {0xA2, 0x001D}, // KEY_LEFTCTRL
+	30: {0x41, 0x001E}, // KEY_A
+	31: {0x53, 0x001F}, // KEY_S
+	32: {0x44, 0x0020}, // KEY_D
+	33: {0x46, 0x0021}, // KEY_F
+	34: {0x47, 0x0022}, // KEY_G
+	35: {0x48, 0x0023}, // KEY_H
+	36: {0x4A, 0x0024}, // KEY_J
+	37: {0x4B, 0x0025}, // KEY_K
+	38: {0x4C, 0x0026}, // KEY_L
+	39: {0xBA, 0x0027}, // KEY_SEMICOLON
+	40: {0xDE, 0x0028}, // KEY_APOSTROPHE
+	41: {0xC0, 0x0029}, // KEY_GRAVE
+	42: {0xA0, 0x002A}, // KEY_LEFTSHIFT
+	43: {0xDC, 0x002B}, // KEY_BACKSLASH
+	44: {0x5A, 0x002C}, // KEY_Z
+	45: {0x58, 0x002D}, // KEY_X
+	46: {0x43, 0x002E}, // KEY_C
+	47: {0x56, 0x002F}, // KEY_V
+	48: {0x42, 0x0030}, // KEY_B
+	49: {0x4E, 0x0031}, // KEY_N
+	50: {0x4D, 0x0032}, // KEY_M
+	51: {0xBC, 0x0033}, // KEY_COMMA
+	52: {0xBE, 0x0034}, // KEY_DOT
+	53: {0xBF, 0x0035}, // KEY_SLASH
+	54: {0xA1, 0x0036}, // KEY_RIGHTSHIFT
+	55: {0x6A, 0x0037}, // KEY_KPASTERISK
+	56: {0xA4, 0x0038}, // KEY_LEFTALT
+	57: {0x20, 0x0039}, // KEY_SPACE
+	58: {0x14, 0x003A}, // KEY_CAPSLOCK
+	59: {0x70, 0x003B}, // KEY_F1
+	60: {0x71, 0x003C},
+	61: {0x72, 0x003D},
+	62: {0x73, 0x003E},
+	63: {0x74, 0x003F},
+	64: {0x75, 0x0040},
+	65: {0x76, 0x0041},
+	66: {0x77, 0x0042},
+	67: {0x78, 0x0043},
+	68: {0x79, 0x0044}, // KEY_F10
+	69: {0x90, 0x0045}, // KEY_NUMLOCK
+	70: {0x91, 0x0046}, // KEY_SCROLLLOCK
+	71: {0x67, 0x0047}, // KEY_KP7
+	72: {0x68, 0x0048}, // KEY_KP8
+	73: {0x69, 0x0049}, // KEY_KP9
+	74: {0x6D, 0x004A}, // KEY_KPMINUS
+	75: {0x64, 0x004B}, // KEY_KP4
+	76: {0x65, 0x004C}, // KEY_KP5
+	77: {0x66, 0x004D}, // KEY_KP6
+	78: {0x6B, 0x004E}, // KEY_KPPLUS
+	79: {0x61, 0x004F}, // KEY_KP1
+	80: {0x62, 0x0050}, // KEY_KP2
+	81: {0x63, 0x0051}, // KEY_KP3
+	82: {0x60, 0x0052}, // KEY_KP0
+	83: {0x6E, 0x0053}, // KEY_KPDOT
+	86: {0xE2, 0x0056}, // KEY_102ND
+	87: {0x7A, 0x0057}, // KEY_F11
+	88: {0x7B, 0x0058}, // KEY_F12
+
+	96:  {0xE0, 0x0E1C}, // KEY_KPENTER
+	97:  {0xA3, 0x0E1D}, // KEY_RIGHTCTRL
+	98:  {0x6F, 0x0E35}, // KEY_KPSLASH
+	99:  {0x2C, 0x0E37}, // KEY_SYSRQ
+	100: {0xA5, 0x0E38}, // KEY_RIGHTALT
+	102: {0x24, 0x0E47}, // KEY_HOME
+	103: {0x26, 0xE048}, // KEY_UP
+	104: {0x21, 0x0E49}, // KEY_PAGEUP
+	105: {0x25, 0xE04B}, // KEY_LEFT
+	106: {0x27, 0xE04D}, // KEY_RIGHT
+	107: {0x23, 0x0E4F}, // KEY_END
+	108: {0x28, 0xE050}, // KEY_DOWN
+	109: {0x22, 0x0E51}, // KEY_PAGEDOWN
+	110: {0x2D, 0x0E52}, // KEY_INSERT
+	111: {0x2E, 0x0E53}, // KEY_DELETE
+	113: {0xAD, 0xE020}, // KEY_MUTE
+	114: {0xAE, 0xE02E}, // KEY_VOLUMEDOWN
+	115: {0xAF, 0xE030}, // KEY_VOLUMEUP
+	119: {0x13, 0x0E45}, // KEY_PAUSE
+	125: {0x5B, 0x0E5B}, // KEY_LEFTMETA
+	126: {0x5C, 0x0E5C}, // KEY_RIGHTMETA
+	127: {0x5D, 0x0E5D}, // KEY_COMPOSE
+	163: {0xB0, 0xE019}, // KEY_NEXTSONG
+	164: {0xB3, 0xE022}, // KEY_PLAYPAUSE
+	165: {0xB1, 0xE010}, // KEY_PREVIOUSSONG
+	166: {0xB2, 0xE024}, // KEY_STOPCD
+
+	183: {0x7C, 0x005B}, // KEY_F13
+	184: {0x7D, 0x005C},
+	185: {0x7E, 0x005D},
+	186: {0x7F, 0x0063},
+	187: {0x80, 0x0064},
+	188: {0x81, 0x0065},
+	189: {0x82, 0x0066},
+	190: {0x83, 0x0067},
+	191: {0x84, 0x0068},
+	192: {0x85, 0x0069},
+	193: {0x86, 0x006A},
+	194: {0x87, 0x006B}, // KEY_F24
+}
+
+// evdevChars holds the characters of the printable keys on a US layout,
+// unshifted and shifted
+var evdevChars = map[uint16][2]rune{
+	2: {'1', '!'}, 3: {'2', '@'}, 4: {'3', '#'}, 5: {'4', '$'}, 6: {'5', '%'},
+	7: {'6', '^'}, 8: {'7', '&'}, 9: {'8', '*'}, 10: {'9', '('}, 11: {'0', ')'},
+	12: {'-', '_'}, 13: {'=', '+'}, 15: {'\t', '\t'}, 28: {'\r', '\r'}, 57: {' ', ' '},
+	16: {'q', 'Q'}, 17: {'w', 'W'}, 18: {'e', 'E'}, 19: {'r', 'R'}, 20: {'t', 'T'},
+	21: {'y', 'Y'}, 22: {'u', 'U'}, 23: {'i', 'I'}, 24: {'o', 'O'}, 25: {'p', 'P'},
+	26: {'[', '{'}, 27: {']', '}'}, 30: {'a', 'A'}, 31: {'s', 'S'}, 32: {'d', 'D'},
+	33: {'f', 'F'}, 34: {'g', 'G'}, 35: {'h', 'H'}, 36: {'j', 'J'}, 37: {'k', 'K'},
+	38: {'l', 'L'}, 39: {';', ':'}, 40: {'\'', '"'}, 41: {'`', '~'}, 43: {'\\', '|'},
+	44: {'z', 'Z'}, 45: {'x', 'X'}, 46: {'c', 'C'}, 47: {'v', 'V'}, 48: {'b', 'B'},
+	49: {'n', 'N'}, 50: {'m', 'M'}, 51: {',', '<'}, 52: {'.', '>'}, 53: {'/', '?'},
+}
+
+// evdevButtons maps BTN_LEFT and the following buttons to the
+// button numbers of libuiohook
+var evdevButtons = map[uint16]uint16{
+	0x110: 1, // BTN_LEFT
+	0x111: 2, // BTN_RIGHT
+	0x112: 3, // BTN_MIDDLE
+	0x113: 4, // BTN_SIDE
+	0x114: 5, // BTN_EXTRA
+}
+
+// evdevState is the input state shared by every device of a source,
+// so a shift on the keyboard applies to a click of the mouse
+type evdevState struct {
+	mu   sync.Mutex
+	mask Modifiers
+	x, y int16
+
+	dragged   bool
+	lastClick uint16
+	lastTime  time.Time
+	clicks    uint16
+}
+
+// evdevDevice turns the records of one device into events
+type evdevDevice struct {
+	state *evdevState
+
+	// the motion and scrolling of the current frame
+	dx, dy        int32
+	wheel, hwheel int32
+	dropped       bool
+}
+
+// feed handles a record and appends the events it completes to out
+func (d *evdevDevice) feed(r evdevRecord, out []Event) []Event {
+	when := time.Unix(r.sec, r.usec*int64(time.Microsecond))
+
+	switch r.typ {
+	case evSyn:
+		switch r.code {
+		case synReport:
+			if d.dropped {
+				// the frame is incomplete, the next one is whole again
+				d.dropped = false
+				d.reset()
+				return out
+			}
+			out = d.flush(when, out)
+		case synDropped:
+			d.dropped = true
+		}
+	case evRel:
+		switch r.code {
+		case relX:
+			d.dx += r.value
+		case relY:
+			d.dy += r.value
+		case relWheel:
+			d.wheel += r.value
+		case relHWheel:
+			d.hwheel += r.value
+		}
+	case evKey:
+		if d.dropped {
+			return out
+		}
+		out = d.motion(when, out)
+		if button, ok := evdevButtons[r.code]; ok {
+			return d.button(when, button, r.value, out)
+		}
+		return d.key(when, r.code, r.value, out)
+	}
+
+	return out
+}
+
+func (d *evdevDevice) reset() {
+	d.dx, d.dy, d.wheel, d.hwheel = 0, 0, 0, 0
+}
+
+// flush appends the motion and the scrolling of the frame
+func (d *evdevDevice) flush(when time.Time, out []Event) []Event {
+	out = d.motion(when, out)
+
+	s := d.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// evdev scrolls up and right with positive values
+	if d.wheel != 0 {
+		out = append(out, s.event(when, Event{Kind: MouseWheel, Clicks: 1,
+			WheelType: WheelUnitScroll, Amount: 3, Rotation: -d.wheel, Direction: uint8(WheelVertical)}))
+	}
+	if d.hwheel != 0 {
+		out = append(out, s.event(when, Event{Kind: MouseWheel, Clicks: 1,
+			WheelType: WheelUnitScroll, Amount: 3, Rotation: d.hwheel, Direction: uint8(WheelHorizontal)}))
+	}
+
+	d.reset()
+	return out
+}
+
+// motion appends the pointer motion of the frame so far
+func (d *evdevDevice) motion(when time.Time, out []Event) []Event {
+	if d.dx == 0 && d.dy == 0 {
+		return out
+	}
+
+	s := d.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.x = clampInt16(int32(s.x) + d.dx)
+	s.y = clampInt16(int32(s.y) + d.dy)
+	d.dx, d.dy = 0, 0
+
+	kind := Kind(MouseMove)
+	if s.mask&ModButtons != 0 {
+		kind = MouseDrag
+		s.dragged = true
+	}
+	return append(out, s.event(when, Event{Kind: kind}))
+}
+
+// moveTo adds the motion to the absolute position x, y to the frame,
+// for the sources that do not report relative motion
+func (d *evdevDevice) moveTo(x, y int16) {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+
+	d.dx = int32(x) - int32(d.state.x)
+	d.dy = int32(y) - int32(d.state.y)
+}
+
+func (d *evdevDevice) button(when time.Time, button uint16, value int32, out []Event) []Event {
+	s := d.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bit := ModButton1 << (button - 1)
+	switch value {
+	case 1:
+		if s.lastClick == button && when.Sub(s.lastTime) <= evdevClickTime {
+			s.clicks++
+		} else {
+			s.clicks = 1
+		}
+		s.lastClick, s.lastTime = button, when
+		s.dragged = false
+
+		s.mask |= bit
+		out = append(out, s.event(when, Event{Kind: MouseDown, Button: button, Clicks: s.clicks}))
+	case 0:
+		s.mask &^= bit
+		out = append(out, s.event(when, Event{Kind: MouseHold, Button: button, Clicks: s.clicks}))
+		if !s.dragged {
+			out = append(out, s.event(when, Event{Kind: MouseUp, Button: button, Clicks: s.clicks}))
+		}
+	}
+
+	return out
+}
+
+func (d *evdevDevice) key(when time.Time, code uint16, value int32, out []Event) []Event {
+	k, ok := evdevKeys[code]
+	if !ok {
+		return out
+	}
+
+	s := d.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := keyModifiers[Code(k.raw)]
+	switch {
+	case value == 0:
+		if m&ModLocks == 0 {
+			s.mask &^= m
+		}
+		return append(out, s.event(when, Event{Kind: KeyUp, Keycode: k.code, Rawcode: k.raw, Keychar: CharUndefined}))
+	case value == keyRepeat:
+	case m&ModLocks != 0:
+		s.mask ^= m
+	default:
+		s.mask |= m
+	}
+
+	out = append(out, s.event(when, Event{Kind: KeyDown, Keycode: k.code, Rawcode: k.raw, Keychar: CharUndefined}))
+	if c, ok := evdevChars[code]; ok {
+		out = append(out, s.event(when, Event{Kind: KeyHold, Rawcode: k.raw, Keychar: s.char(c)}))
+	}
+
+	return out
+}
+
+// char picks the character of a key for the held modifiers,
+// caps lock only shifts letters
+func (s *evdevState) char(c [2]rune) rune {
+	shift := s.mask&ModShift != 0
+	if s.mask&ModCapsLock != 0 && c[0] >= 'a' && c[0] <= 'z' {
+		shift = !shift
+	}
+	if shift {
+		return c[1]
+	}
+	return c[0]
+}
+
+// event fills in the shared fields of e, s.mu has to be held
+func (s *evdevState) event(when time.Time, e Event) Event {
+	e.When = when
+	e.NativeTime = uint64(when.UnixMicro())
+	e.Mask = uint16(s.mask)
+	if e.Kind >= MouseUp && e.Kind <= MouseWheel {
+		e.X, e.Y = s.x, s.y
+	}
+	return e
+}
+
+func clampInt16(v int32) int16 {
+	return int16(max(-1<<15, min(1<<15-1, v)))
+}
@@ -0,0 +1,300 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// DefaultEvdevDir is the directory of the evdev devices
+const DefaultEvdevDir = "/dev/input"
+
+// EvdevOption configures a Source created by NewEvdevSource
+type EvdevOption func(*evdevSource)
+
+// EvdevDir reads the event* devices of dir instead of DefaultEvdevDir
+func EvdevDir(dir string) EvdevOption {
+	return func(s *evdevSource) {
+		s.dir = dir
+	}
+}
+
+// EvdevDevices reads the given files instead of a directory, without
+// hot-plugging. They can be devices, pipes or recorded input_event
+// streams, a file that ends is closed.
+func EvdevDevices(paths ...string) EvdevOption {
+	return func(s *evdevSource) {
+		s.paths = paths
+	}
+}
+
+// NewEvdevSource returns a Source reading the evdev devices of the
+// kernel, which works without X11, under Wayland and on a console.
+// Devices plugged in while it runs are picked up through inotify.
+//
+// Select it before starting a Hook with WithSource or SetSource.
+// Reading /dev/input usually takes root or the input group. As
+// evdev only reports relative motion the pointer starts at 0, 0,
+// and the key characters are the ones of a US layout.
+func NewEvdevSource(opts ...EvdevOption) Source {
+	s := &evdevSource{dir: DefaultEvdevDir}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type evdevSource struct {
+	dir   string
+	paths []string
+
+	// mu guards the fields below, stopped is set by Stop
+	// before it waits for the readers
+	mu      sync.Mutex
+	ev      chan Event
+	done    chan struct{}
+	stopped bool
+	state   *evdevState
+	files   map[string]*os.File
+	notify  *os.File
+	reading sync.WaitGroup
+}
+
+func (s *evdevSource) Start(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.ev != nil {
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.ev = make(chan Event, evdevBuffer)
+	// queued before any reader starts, so it comes first
+	// and the fresh buffer has room for it
+	s.ev <- Event{Kind: HookEnabled}
+	s.done = make(chan struct{})
+	s.stopped = false
+	s.state = &evdevState{}
+	s.files = make(map[string]*os.File)
+
+	var err error
+	if len(s.paths) != 0 {
+		err = s.openPaths()
+	} else {
+		err = s.openDir()
+	}
+	if err != nil {
+		s.stopLocked()
+		s.mu.Unlock()
+		s.reading.Wait()
+		return err
+	}
+	foreignSourceStarted("evdev", 1)
+
+	s.mu.Unlock()
+	return nil
+}
+
+// openPaths opens the files of EvdevDevices, s.mu has to be held
+func (s *evdevSource) openPaths() error {
+	for _, path := range s.paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("hook: opening input device: %w", err)
+		}
+		s.read(path, f)
+	}
+	return nil
+}
+
+// openDir watches s.dir and opens its devices, s.mu has to be held
+//
+// It fails if devices exist but none of them can be opened,
+// which usually means missing permissions.
+func (s *evdevSource) openDir() error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("hook: watching %s: %w", s.dir, err)
+	}
+	// non blocking, so Close interrupts a pending Read
+	s.notify = os.NewFile(uintptr(fd), "inotify")
+
+	mask := uint32(syscall.IN_CREATE | syscall.IN_ATTRIB | syscall.IN_MOVED_TO)
+	if _, err := syscall.InotifyAddWatch(fd, s.dir, mask); err != nil {
+		return fmt.Errorf("hook: watching %s: %w", s.dir, err)
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("hook: reading %s: %w", s.dir, err)
+	}
+
+	var first error
+	for _, entry := range entries {
+		if !isEvdevName(entry.Name()) {
+			continue
+		}
+		if err := s.open(entry.Name()); err != nil && first == nil {
+			first = err
+		}
+	}
+	if len(s.files) == 0 && first != nil {
+		return fmt.Errorf("hook: opening input device: %w", first)
+	}
+
+	s.reading.Add(1)
+	go s.watch(s.notify)
+	return nil
+}
+
+// open starts reading the device name of s.dir unless it is read
+// already, s.mu has to be held
+func (s *evdevSource) open(name string) error {
+	path := filepath.Join(s.dir, name)
+	if _, ok := s.files[path]; ok {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	s.read(path, f)
+	return nil
+}
+
+// read starts a reader of f, s.mu has to be held
+func (s *evdevSource) read(path string, f *os.File) {
+	s.files[path] = f
+	s.reading.Add(1)
+	go s.readDevice(path, f, &evdevDevice{state: s.state}, s.ev, s.done)
+}
+
+func (s *evdevSource) readDevice(path string, f *os.File, d *evdevDevice, ev chan<- Event, done <-chan struct{}) {
+	defer s.reading.Done()
+	defer s.closeDevice(path, f)
+
+	r := bufio.NewReaderSize(f, evdevRecordSize*64)
+	rec := make([]byte, evdevRecordSize)
+	out := []Event{}
+	for {
+		// ends with io.EOF for a recording, ENODEV once
+		// the device is unplugged and ErrClosed on Stop
+		if _, err := io.ReadFull(r, rec); err != nil {
+			return
+		}
+
+		out = d.feed(parseEvdevRecord(rec), out[:0])
+		for _, e := range out {
+			select {
+			case ev <- e:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+func (s *evdevSource) closeDevice(path string, f *os.File) {
+	s.mu.Lock()
+	if s.files[path] == f {
+		delete(s.files, path)
+	}
+	s.mu.Unlock()
+
+	f.Close()
+}
+
+// watch opens the devices created in s.dir until notify is closed
+func (s *evdevSource) watch(notify *os.File) {
+	defer s.reading.Done()
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := notify.Read(buf)
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			end := min(start+int(event.Len), n)
+			name := strings.TrimRight(string(buf[start:end]), "\x00")
+			off = end
+
+			if s.stopped || !isEvdevName(name) {
+				continue
+			}
+			// the permissions of a new device are set after its
+			// creation, a failed open is retried on IN_ATTRIB
+			if err := s.open(name); err != nil && !errors.Is(err, os.ErrPermission) {
+				hookLog("failed to open %s: %v\n", name, err)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *evdevSource) Events() <-chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ev
+}
+
+func (s *evdevSource) Stop() {
+	s.mu.Lock()
+	if s.ev == nil {
+		s.mu.Unlock()
+		return
+	}
+	ev := s.ev
+	s.stopLocked()
+	foreignSourceStarted("evdev", -1)
+	s.mu.Unlock()
+
+	s.reading.Wait()
+	close(ev)
+}
+
+// stopLocked closes the devices and ends the readers without waiting
+// for them, s.mu has to be held
+func (s *evdevSource) stopLocked() {
+	s.stopped = true
+	close(s.done)
+	if s.notify != nil {
+		s.notify.Close()
+		s.notify = nil
+	}
+	for _, f := range s.files {
+		f.Close()
+	}
+	s.ev, s.done = nil, nil
+}
+
+func isEvdevName(name string) bool {
+	return strings.HasPrefix(name, "event")
+}
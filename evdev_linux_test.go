@@ -0,0 +1,178 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// ctrlA is a recording of ctrl+a being typed
+var ctrlA = recordEvdev(
+	evKeyRec(29, 1), evSynRec(),
+	evKeyRec(30, 1), evSynRec(),
+	evKeyRec(30, 0), evSynRec(),
+	evKeyRec(29, 0), evSynRec(),
+)
+
+// runEvdev runs a Hook reading src that reports ctrl+a on fired
+func runEvdev(t *testing.T, src Source) <-chan bool {
+	t.Helper()
+
+	h := New(WithSource(src))
+	fired := make(chan bool, 1)
+	if _, err := h.RegisterHotkey(KeyDown, "ctrl+a", func(e Event) {
+		fired <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch, err := h.StartContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(h.Close)
+	h.Process(ch)
+
+	return fired
+}
+
+func waitFired(t *testing.T, fired <-chan bool) {
+	t.Helper()
+
+	select {
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for ctrl+a")
+	case <-fired:
+	}
+}
+
+func TestEvdevSourceRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyboard")
+	if err := os.WriteFile(path, ctrlA, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFired(t, runEvdev(t, NewEvdevSource(EvdevDevices(path))))
+}
+
+func TestEvdevSourceEnabledFirst(t *testing.T) {
+	// more events than the buffer holds, read before Start returns
+	recs := []evdevRecord{}
+	for range evdevBuffer {
+		recs = append(recs, evKeyRec(30, 1), evKeyRec(30, 0))
+	}
+	path := filepath.Join(t.TempDir(), "keyboard")
+	if err := os.WriteFile(path, recordEvdev(recs...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	src := NewEvdevSource(EvdevDevices(path))
+	started := make(chan error, 1)
+	go func() {
+		started <- src.Start(context.Background())
+	}()
+	select {
+	case err := <-started:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for Start")
+	}
+	defer src.Stop()
+
+	if e := <-src.Events(); e.Kind != HookEnabled {
+		t.Fatal("Expected HookEnabled first, got", e)
+	}
+}
+
+func TestEvdevSourcePipe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fifo")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// opening the fifo blocks until both ends are open
+	writer := make(chan *os.File, 1)
+	go func() {
+		w, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			t.Error(err)
+		}
+		writer <- w
+	}()
+
+	fired := runEvdev(t, NewEvdevSource(EvdevDevices(path)))
+	w := <-writer
+	if w == nil {
+		return
+	}
+	defer w.Close()
+
+	if _, err := w.Write(ctrlA); err != nil {
+		t.Fatal(err)
+	}
+	waitFired(t, fired)
+}
+
+func TestEvdevSourceHotplug(t *testing.T) {
+	dir := t.TempDir()
+	// not an event device
+	if err := os.WriteFile(filepath.Join(dir, "mice"), ctrlA, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fired := runEvdev(t, NewEvdevSource(EvdevDir(dir)))
+	select {
+	case <-fired:
+		t.Fatal("Expected only event devices to be read")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// plug the device in at once, like udev does
+	tmp := filepath.Join(t.TempDir(), "event3")
+	if err := os.WriteFile(tmp, ctrlA, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "event3")); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFired(t, fired)
+}
+
+func TestEvdevSourceStop(t *testing.T) {
+	src := NewEvdevSource(EvdevDir(t.TempDir()))
+	for i := 0; i < 2; i++ {
+		if err := src.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		events := src.Events()
+		if e := <-events; e.Kind != HookEnabled {
+			t.Fatal("Expected HookEnabled first, got", e)
+		}
+
+		src.Stop()
+		if _, ok := <-events; ok {
+			t.Fatal("Expected Stop to close the events")
+		}
+	}
+}
+
+func TestEvdevSourceErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	err := NewEvdevSource(EvdevDevices(missing)).Start(context.Background())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("Expected a missing device, got", err)
+	}
+
+	err = NewEvdevSource(EvdevDir(missing)).Start(context.Background())
+	if err == nil {
+		t.Fatal("Expected a missing directory to fail")
+	}
+}
@@ -0,0 +1,216 @@
+package hook
+
+import (
+	"encoding/binary"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// recordEvdev encodes records the way the kernel writes them
+func recordEvdev(recs ...evdevRecord) []byte {
+	out := []byte{}
+	for _, r := range recs {
+		if strconv.IntSize == 64 {
+			out = binary.NativeEndian.AppendUint64(out, uint64(r.sec))
+			out = binary.NativeEndian.AppendUint64(out, uint64(r.usec))
+		} else {
+			out = binary.NativeEndian.AppendUint32(out, uint32(r.sec))
+			out = binary.NativeEndian.AppendUint32(out, uint32(r.usec))
+		}
+		out = binary.NativeEndian.AppendUint16(out, r.typ)
+		out = binary.NativeEndian.AppendUint16(out, r.code)
+		out = binary.NativeEndian.AppendUint32(out, uint32(r.value))
+	}
+	return out
+}
+
+func evKeyRec(code uint16, value int32) evdevRecord {
+	return evdevRecord{sec: 10, usec: 500, typ: evKey, code: code, value: value}
+}
+
+func evRelRec(code uint16, value int32) evdevRecord {
+	return evdevRecord{sec: 10, usec: 500, typ: evRel, code: code, value: value}
+}
+
+func evSynRec() evdevRecord {
+	return evdevRecord{sec: 10, usec: 500, typ: evSyn, code: synReport}
+}
+
+// feedEvdev decodes a recorded stream with a fresh device
+func feedEvdev(t *testing.T, stream []byte) []Event {
+	t.Helper()
+
+	if len(stream)%evdevRecordSize != 0 {
+		t.Fatal("Expected whole records, got", len(stream), "bytes")
+	}
+	d := &evdevDevice{state: &evdevState{}}
+	out := []Event{}
+	for len(stream) > 0 {
+		out = d.feed(parseEvdevRecord(stream), out)
+		stream = stream[evdevRecordSize:]
+	}
+	return out
+}
+
+func TestEvdevRecord(t *testing.T) {
+	want := evdevRecord{sec: 1700000000, usec: 123456, typ: evRel, code: relWheel, value: -2}
+	got := parseEvdevRecord(recordEvdev(want))
+	if got != want {
+		t.Fatalf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestEvdevKeys(t *testing.T) {
+	events := feedEvdev(t, recordEvdev(
+		evKeyRec(29, 1), evSynRec(), // KEY_LEFTCTRL
+		evKeyRec(42, 1), evSynRec(), // KEY_LEFTSHIFT
+		evKeyRec(30, 1), evSynRec(), // KEY_A
+		evKeyRec(30, keyRepeat), evSynRec(),
+		evKeyRec(30, 0), evSynRec(),
+		evKeyRec(42, 0), evSynRec(),
+		evKeyRec(240, 1), evSynRec(), // KEY_UNKNOWN
+	))
+
+	want := []struct {
+		kind    Kind
+		raw     uint16
+		keychar rune
+		mask    Modifiers
+	}{
+		{KeyDown, Keycode["left_control"], CharUndefined, ModCtrlLeft},
+		{KeyDown, Keycode["left_shift"], CharUndefined, ModCtrlLeft | ModShiftLeft},
+		{KeyDown, Keycode["a"], CharUndefined, ModCtrlLeft | ModShiftLeft},
+		{KeyHold, Keycode["a"], 'A', ModCtrlLeft | ModShiftLeft},
+		{KeyDown, Keycode["a"], CharUndefined, ModCtrlLeft | ModShiftLeft},
+		{KeyHold, Keycode["a"], 'A', ModCtrlLeft | ModShiftLeft},
+		{KeyUp, Keycode["a"], CharUndefined, ModCtrlLeft | ModShiftLeft},
+		{KeyUp, Keycode["left_shift"], CharUndefined, ModCtrlLeft},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %v", len(want), events)
+	}
+	for i, e := range events {
+		w := want[i]
+		if e.Kind != w.kind || e.Rawcode != w.raw || e.Keychar != w.keychar || Modifiers(e.Mask) != w.mask {
+			t.Errorf("Event %d: got %v with mask %v", i, e, Modifiers(e.Mask))
+		}
+	}
+
+	if events[2].Keycode != 0x001E {
+		t.Error("Expected the VC_A keycode, got", events[2].Keycode)
+	}
+	if !events[0].When.Equal(time.Unix(10, 500*int64(time.Microsecond))) {
+		t.Error("Expected the time of the record, got", events[0].When)
+	}
+}
+
+func TestEvdevCapsLock(t *testing.T) {
+	events := feedEvdev(t, recordEvdev(
+		evKeyRec(58, 1), evKeyRec(58, 0), // KEY_CAPSLOCK
+		evKeyRec(30, 1), evKeyRec(30, 0),
+		evKeyRec(2, 1), evKeyRec(2, 0), // KEY_1
+		evKeyRec(58, 1), evKeyRec(58, 0),
+		evKeyRec(30, 1),
+	))
+
+	chars := []rune{}
+	for _, e := range events {
+		if e.Kind == KeyHold {
+			chars = append(chars, e.Keychar)
+		}
+	}
+	if string(chars) != "A1a" {
+		t.Fatalf("Expected the characters A1a, got %q", string(chars))
+	}
+	if Modifiers(events[len(events)-1].Mask).CapsLock() {
+		t.Fatal("Expected caps lock to be off again")
+	}
+}
+
+func TestEvdevMouse(t *testing.T) {
+	events := feedEvdev(t, recordEvdev(
+		evRelRec(relX, 10), evRelRec(relY, 20), evSynRec(),
+		evKeyRec(0x110, 1), evSynRec(), // BTN_LEFT
+		evKeyRec(0x110, 0), evSynRec(),
+		evKeyRec(0x111, 1), evSynRec(), // BTN_RIGHT
+		evRelRec(relX, -5), evSynRec(),
+		evKeyRec(0x111, 0), evSynRec(),
+		evRelRec(relWheel, 1), evRelRec(relHWheel, -2), evSynRec(),
+	))
+
+	want := []struct {
+		kind   Kind
+		button uint16
+		x, y   int16
+	}{
+		{MouseMove, 0, 10, 20},
+		{MouseDown, 1, 10, 20},
+		{MouseHold, 1, 10, 20},
+		{MouseUp, 1, 10, 20},
+		{MouseDown, 2, 10, 20},
+		{MouseDrag, 0, 5, 20},
+		// no click after a drag
+		{MouseHold, 2, 5, 20},
+		{MouseWheel, 0, 5, 20},
+		{MouseWheel, 0, 5, 20},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %v", len(want), events)
+	}
+	for i, e := range events {
+		w := want[i]
+		if e.Kind != w.kind || e.Button != w.button || e.X != w.x || e.Y != w.y {
+			t.Errorf("Event %d: got %v", i, e)
+		}
+	}
+
+	if Modifiers(events[1].Mask) != ModButton1 || Modifiers(events[5].Mask) != ModButton2 {
+		t.Error("Expected the pressed buttons in the mask")
+	}
+
+	up, _ := events[7].Wheel()
+	if up.Direction != WheelVertical || up.Rotation != -1 || up.Type != WheelUnitScroll {
+		t.Error("Expected a vertical scroll up, got", events[7])
+	}
+	left, _ := events[8].Wheel()
+	if left.Direction != WheelHorizontal || left.Rotation != -2 {
+		t.Error("Expected a horizontal scroll left, got", events[8])
+	}
+}
+
+func TestEvdevDoubleClick(t *testing.T) {
+	click := func(usec int64) []evdevRecord {
+		return []evdevRecord{
+			{sec: 1, usec: usec, typ: evKey, code: 0x110, value: 1},
+			{sec: 1, usec: usec, typ: evKey, code: 0x110, value: 0},
+		}
+	}
+	recs := append(click(0), click(100000)...)
+	recs = append(recs, evdevRecord{sec: 2, typ: evKey, code: 0x110, value: 1})
+
+	clicks := []uint16{}
+	for _, e := range feedEvdev(t, recordEvdev(recs...)) {
+		if e.Kind == MouseDown {
+			clicks = append(clicks, e.Clicks)
+		}
+	}
+	if len(clicks) != 3 || clicks[0] != 1 || clicks[1] != 2 || clicks[2] != 1 {
+		t.Fatal("Expected the click counts 1, 2, 1, got", clicks)
+	}
+}
+
+func TestEvdevDroppedFrame(t *testing.T) {
+	events := feedEvdev(t, recordEvdev(
+		evRelRec(relX, 10),
+		evdevRecord{typ: evSyn, code: synDropped},
+		evKeyRec(30, 1),
+		evRelRec(relY, 10),
+		evSynRec(),
+		evRelRec(relY, 5), evSynRec(),
+	))
+
+	if len(events) != 1 || events[0].Kind != MouseMove || events[0].X != 0 || events[0].Y != 5 {
+		t.Fatal("Expected the dropped frame to be skipped, got", events)
+	}
+}
@@ -65,7 +65,10 @@ func AddEvents(key string, arr ...string) bool {
 	ct := false
 	k := 0
 	for {
-		e := <-s
+		e, ok := <-s
+		if !ok {
+			return false
+		}
 
 		l := len(arr)
 		if l > 0 {
@@ -114,7 +117,10 @@ func AddMouse(btn string, x ...int16) bool {
 
 	ct := false
 	for {
-		e := <-s
+		e, ok := <-s
+		if !ok {
+			return false
+		}
 
 		if len(x) > 1 {
 			if e.Kind == MouseMove && e.X == x[0] && e.Y == x[1] {
@@ -138,7 +144,10 @@ func AddMousePos(x, y int16) bool {
 	s := Start()
 
 	for {
-		e := <-s
+		e, ok := <-s
+		if !ok {
+			return false
+		}
 		if e.Kind == MouseMove && e.X == x && e.Y == y {
 			End()
 			break
@@ -0,0 +1,127 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+#ifndef bridge_h
+#define bridge_h
+
+#include <stdint.h>
+#include <string.h>
+
+// go_event is the fixed layout an event is handed to Go in,
+// it has to match rawEvent in bridge.go field by field.
+typedef struct _go_event {
+	uint64_t time;
+	int32_t rotation;
+	uint32_t keychar;
+	uint16_t mask;
+	uint16_t reserved;
+	uint16_t keycode;
+	uint16_t rawcode;
+	uint16_t button;
+	uint16_t clicks;
+	uint16_t amount;
+	int16_t x;
+	int16_t y;
+	uint8_t type;
+	uint8_t wheel_type;
+	uint8_t direction;
+	uint8_t synthetic;
+	uint8_t pad[2];
+} go_event;
+
+// The events travel from the hook thread to Go in a single producer,
+// single consumer ring. ring_head is only written by the hook thread,
+// ring_tail only by Go, an event that does not fit is dropped so the
+// hook callback never blocks.
+#define GO_EVENT_RING_SIZE 1024
+
+static go_event ring[GO_EVENT_RING_SIZE];
+static uint32_t ring_head = 0;
+static uint32_t ring_tail = 0;
+static uint64_t ring_dropped = 0;
+
+void reset_events() {
+	__atomic_store_n(&ring_head, 0, __ATOMIC_SEQ_CST);
+	__atomic_store_n(&ring_tail, 0, __ATOMIC_SEQ_CST);
+}
+
+// push_event converts event into the ring, it returns false if full.
+bool push_event(iohook_event * const event) {
+	uint32_t head = __atomic_load_n(&ring_head, __ATOMIC_RELAXED);
+	uint32_t tail = __atomic_load_n(&ring_tail, __ATOMIC_ACQUIRE);
+	if (head - tail >= GO_EVENT_RING_SIZE) {
+		__atomic_add_fetch(&ring_dropped, 1, __ATOMIC_RELAXED);
+		return false;
+	}
+
+	go_event *out = &ring[head % GO_EVENT_RING_SIZE];
+	memset(out, 0, sizeof(go_event));
+	out->type = event->type;
+	out->time = event->time;
+	out->mask = event->mask;
+	out->reserved = event->reserved;
+	out->synthetic = event->injected;
+
+	switch (event->type) {
+		case EVENT_KEY_PRESSED:
+		case EVENT_KEY_RELEASED:
+		case EVENT_KEY_TYPED:
+			out->keycode = event->data.keyboard.keycode;
+			out->rawcode = event->data.keyboard.rawcode;
+			out->keychar = event->data.keyboard.keychar;
+			break;
+		case EVENT_MOUSE_PRESSED:
+		case EVENT_MOUSE_RELEASED:
+		case EVENT_MOUSE_CLICKED:
+		case EVENT_MOUSE_MOVED:
+		case EVENT_MOUSE_DRAGGED:
+			out->button = event->data.mouse.button;
+			out->clicks = event->data.mouse.clicks;
+			out->x = event->data.mouse.x;
+			out->y = event->data.mouse.y;
+			break;
+		case EVENT_MOUSE_WHEEL:
+			out->clicks = event->data.wheel.clicks;
+			out->x = event->data.wheel.x;
+			out->y = event->data.wheel.y;
+			out->wheel_type = event->data.wheel.type;
+			out->amount = event->data.wheel.amount;
+			out->rotation = event->data.wheel.rotation;
+			out->direction = event->data.wheel.direction;
+			break;
+		default:
+			break;
+	}
+
+	__atomic_store_n(&ring_head, head + 1, __ATOMIC_RELEASE);
+	return true;
+}
+
+// take_events copies up to max buffered events to buf
+// and returns how many it copied.
+int take_events(go_event *buf, int max) {
+	uint32_t tail = __atomic_load_n(&ring_tail, __ATOMIC_RELAXED);
+	uint32_t head = __atomic_load_n(&ring_head, __ATOMIC_ACQUIRE);
+
+	int n = 0;
+	for (; tail != head && n < max; tail++, n++) {
+		buf[n] = ring[tail % GO_EVENT_RING_SIZE];
+	}
+
+	__atomic_store_n(&ring_tail, tail, __ATOMIC_RELEASE);
+	return n;
+}
+
+// dropped_events returns the number of events that did not fit the ring.
+uint64_t dropped_events() {
+	return __atomic_load_n(&ring_dropped, __ATOMIC_RELAXED);
+}
+
+#endif
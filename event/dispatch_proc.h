@@ -12,77 +12,32 @@
 #define dispatch_proc_h
 
 // #include "pub.h"
-// #include "../chan/eb_chan.h"
 
 void dispatch_proc(iohook_event * const event) {
-    if (!sending) { return; }
-
-	// leaking memory? hope not
-    char* buffer = calloc(200, sizeof(char));
+	if (!sending) { return; }
 
 	switch (event->type) {
-	    case EVENT_HOOK_ENABLED:
-	    case EVENT_HOOK_DISABLED:
-	        sprintf(buffer,
-			"{\"id\":%i,\"time\":%" PRIu64 ",\"mask\":%hu,\"reserved\":%hu}",
-	        event->type, event->time, event->mask,event->reserved);
-	    break;	// send it?
+		case EVENT_HOOK_ENABLED:
+		case EVENT_HOOK_DISABLED:
 		case EVENT_KEY_PRESSED:
 		case EVENT_KEY_RELEASED:
 		case EVENT_KEY_TYPED:
-           sprintf(buffer,
-                "{\"id\":%i,\"time\":%" PRIu64 ",\"mask\":%hu,\"reserved\":%hu,\"keycode\":%hu,\"rawcode\":%hu,\"keychar\":%d}",
-                event->type, event->time, event->mask,event->reserved,
-                event->data.keyboard.keycode,
-                event->data.keyboard.rawcode,
-                event->data.keyboard.keychar);
-            break;
 		case EVENT_MOUSE_PRESSED:
 		case EVENT_MOUSE_RELEASED:
 		case EVENT_MOUSE_CLICKED:
 		case EVENT_MOUSE_MOVED:
 		case EVENT_MOUSE_DRAGGED:
-			sprintf(buffer,
-				"{\"id\":%i,\"time\":%" PRIu64 ",\"mask\":%hu,\"reserved\":%hu,\"x\":%hd,\"y\":%hd,\"button\":%u,\"clicks\":%u}",
-				event->type, event->time, event->mask,event->reserved,
-				event->data.mouse.x,
-				event->data.mouse.y,
-				event->data.mouse.button,
-				event->data.mouse.clicks);
-			break;
 		case EVENT_MOUSE_WHEEL:
-			sprintf(buffer,
-				"{\"id\":%i,\"time\":%" PRIu64 ",\"mask\":%hu,\"reserved\":%hu,\"clicks\":%hu,\"x\":%hd,\"y\":%hd,\"type\":%d,\"ammount\":%hu,\"rotation\":%d,\"direction\":%d}",
-				event->type, event->time, event->mask, event->reserved,
-				event->data.wheel.clicks,
-				event->data.wheel.x,
-				event->data.wheel.y,
-   				event->data.wheel.type,
-   				event->data.wheel.amount,
-   				event->data.wheel.rotation,
-   				event->data.wheel.direction);
 			break;
 		default:
-		    fprintf(stderr,"\nError on file: %s, unusual event->type: %i\n",__FILE__,event->type);
+			fprintf(stderr,"\nError on file: %s, unusual event->type: %i\n",__FILE__,event->type);
 			return;
 	}
-	
-	// to-do remove this for
-	int i;
-	for (i = 0; i < 5; i++) {
-        switch (eb_chan_try_send(events, buffer)) { // never block the hook callback
-            case eb_chan_res_ok:
-				i=5;
-				break;
-            default:
-				if (i == 4) { // let's not leak memory
-					free(buffer);
-				}
-				continue;
-        }
-    }
 
-	// fprintf(stdout, "----%s\n",	 buffer);
+	// never block the hook callback, a full ring drops the event
+	if (push_event(event)) {
+		go_notify();
+	}
 }
 
 void dispatch_proc_end(iohook_event * const event) {
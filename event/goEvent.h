@@ -16,40 +16,21 @@
 
 #include <stdlib.h>
 #include "pub.h"
-// #include "../chan/eb_chan.h"
 #include "dispatch_proc.h"
 
-void go_send(char*);
 void go_sleep(void);
 
-void start_ev(){
-    events = eb_chan_create(1024);
-    eb_chan_retain(events);
+int start_ev(){
+	reset_events();
 	sending = true;
 	// add_event("q");
-	add_event_async();
-}
-
-void pollEv(){
-    if (events == NULL) { return; }
-	
-    for (;eb_chan_buf_len(events)!=0;) {
-        char* tmp;
-        if (eb_chan_try_recv(events, (const void**) &tmp) 
-			== eb_chan_res_ok) {
-			// send a char
-            go_send(tmp);
-            free(tmp);
-        } else {
-            //
-        }
-    }
+	return add_event_async();
 }
 
+// endPoll stops buffering events, the events still in
+// the ring are left for take_events
 void endPoll(){
 	sending = false;
-	pollEv(); // remove last things from channel
-	eb_chan_release(events);
 }
 
 int add_event(char *key_event) {
@@ -60,8 +41,8 @@ int add_event(char *key_event) {
 	return cstatus;
 }
 
-void add_event_async(){
-	add_hook(&dispatch_proc);
+int add_event_async(){
+	return add_hook(&dispatch_proc);
 }
 
 int add_hook(dispatcher_t dispatch) {
@@ -170,4 +151,45 @@ int stop_event(){
 	return status;
 }
 
+// post_event posts a synthesized event through hook_post_event
+int post_event(uint8_t type, uint16_t keycode, uint16_t button,
+		int16_t x, int16_t y, int32_t rotation, uint8_t direction) {
+	#if defined(USE_X11)
+	if (properties_disp == NULL) {
+		return IOHOOK_ERROR_X_OPEN_DISPLAY;
+	}
+	#endif
+
+	iohook_event event;
+	memset(&event, 0, sizeof(event));
+	event.type = (event_type) type;
+
+	switch (event.type) {
+		case EVENT_KEY_PRESSED:
+		case EVENT_KEY_RELEASED:
+			event.data.keyboard.keycode = keycode;
+			break;
+
+		case EVENT_MOUSE_WHEEL:
+			// x11 has no wheel events, PostWheel clicks its buttons there
+			event.data.wheel.clicks = 1;
+			event.data.wheel.x = x;
+			event.data.wheel.y = y;
+			event.data.wheel.type = WHEEL_UNIT_SCROLL;
+			event.data.wheel.amount = 1;
+			event.data.wheel.rotation = rotation;
+			event.data.wheel.direction = direction;
+			break;
+
+		default:
+			event.data.mouse.button = button;
+			event.data.mouse.clicks = 1;
+			event.data.mouse.x = x;
+			event.data.mouse.y = y;
+			break;
+	}
+
+	return hook_post_event(&event);
+}
+
 #endif
\ No newline at end of file
@@ -35,9 +35,8 @@
 #include <string.h>
 
 #include "../hook/iohook.h"
-#include "../chan/eb_chan.h"
+#include "bridge.h"
 
-eb_chan events;
 bool sending = false;
 
 int vccode[100];
@@ -50,10 +49,13 @@ int event_status;
 int rrevent;
 
 int add_hook(dispatcher_t dispatch);
-void add_event_async();
+int add_event_async();
 int add_event(char *key_event);
 int stop_event();
 
+// go_notify wakes the Go side up once events are buffered, see extern.go
+void go_notify(void);
+
 void dispatch_proc_end(iohook_event * const event);
 // int allEvent(char *key_event);
 int allEvent(char *key_event, int vcode[], int size);
@@ -13,7 +13,7 @@ func main() {
 	hook.SetLogLevel(hook.Silent)
 	binds := []string{}
 	kinds := []string{}
-	flag.StringArrayVar(&binds, "bind", []string{}, "Usage: --bind <key>")
+	flag.StringArrayVar(&binds, "bind", []string{}, "Usage: --bind <hotkey>, for example ctrl+shift+q, mleft or the key list ctrl,shift,q")
 	flag.StringArrayVar(&kinds, "kind", []string{}, "Usage: --kind <type>")
 	flag.Parse()
 
@@ -31,11 +31,7 @@ func main() {
 	}
 
 	for i, bind := range binds {
-		if isMouseBind(bind) {
-			registerMouseBind(bind, kindsInt[i])
-		} else {
-			registerKeyBind(bind, kindsInt[i])
-		}
+		registerBind(bind, kindsInt[i])
 	}
 
 	fmt.Println("Starting...")
@@ -44,46 +40,23 @@ func main() {
 	<-hook.Process(s)
 }
 
-func isMouseBind(bind string) bool {
-	return bind == "mleft" || bind == "mright" || bind == "mcenter" || strings.HasPrefix(bind, "wheel")
-}
-
-func registerMouseBind(bind string, kind uint8) {
+func registerBind(bind string, kind uint8) {
 	hookKind := hook.Kind(kind)
 
-	switch hookKind {
-	case hook.MouseDown:
-		if err := hook.Register(hook.MouseDown, []string{bind}, func(e hook.Event) {
-			fmt.Printf("%d:%s\n", e.Kind, bind)
-		}); err != nil {
-			panic(err)
-		}
-	case hook.MouseHold:
-		if err := hook.Register(hook.MouseHold, []string{bind}, func(e hook.Event) {
-			fmt.Printf("%d:%s\n", e.Kind, bind)
-		}); err != nil {
-			panic(err)
-		}
-	default:
-		panic(fmt.Sprintf("invalid bindType: %d", kind))
+	cb := func(e hook.Event) {
+		fmt.Printf("%d:%s\n", e.Kind, bind)
 	}
-}
-
-func registerKeyBind(bind string, kind uint8) {
-	parts := strings.Split(bind, ",")
 
-	hookKind := hook.Kind(kind)
 	switch hookKind {
-	case hook.KeyDown:
-		if err := hook.Register(hook.KeyDown, parts, func(e hook.Event) {
-			fmt.Printf("%d:%s\n", e.Kind, bind)
-		}); err != nil {
-			panic(err)
+	case hook.KeyDown, hook.KeyUp, hook.MouseDown, hook.MouseHold:
+		var err error
+		if strings.Contains(bind, ",") {
+			// a list of key names, like ctrl,shift,q
+			_, err = hook.Register(hookKind, strings.Split(bind, ","), cb)
+		} else {
+			_, err = hook.RegisterHotkey(hookKind, bind, cb)
 		}
-	case hook.KeyUp:
-		if err := hook.Register(hook.KeyUp, parts, func(e hook.Event) {
-			fmt.Printf("%d:%s\n", e.Kind, bind)
-		}); err != nil {
+		if err != nil {
 			panic(err)
 		}
 	default:
@@ -0,0 +1,349 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// fakeBuffer is the number of events a FakeSource
+// buffers before its helpers block
+const fakeBuffer = 64
+
+// FakeSource is an in-memory Source for tests. Its helpers send
+// the events the native hook reports for the same input, with the
+// modifier mask and the pointer position kept up to date.
+//
+//	src := hook.NewFakeSource()
+//	h := hook.New(hook.WithSource(src))
+//	h.RegisterHotkey(hook.KeyDown, "ctrl+c", cb)
+//	go h.Run(ctx)
+//	src.Tap("ctrl+c")
+//
+// The helpers block while the buffer of the source is full
+// and return ErrSourceStopped if it is not started.
+type FakeSource struct {
+	// Clock returns the When of the sent events, time.Now if nil
+	Clock func() time.Time
+
+	// mu guards the fields below, sending is held by the helpers
+	// while they send so Stop can close ev once they are done
+	mu      sync.Mutex
+	sending sync.RWMutex
+	ev      chan Event
+	done    chan struct{}
+	mask    Modifiers
+	x, y    int16
+}
+
+// NewFakeSource returns a FakeSource that is not started
+func NewFakeSource() *FakeSource {
+	return &FakeSource{}
+}
+
+// Start starts the source and sends HookEnabled
+func (f *FakeSource) Start(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	if f.ev != nil {
+		f.mu.Unlock()
+		return nil
+	}
+	f.ev = make(chan Event, fakeBuffer)
+	f.done = make(chan struct{})
+	f.mu.Unlock()
+
+	return f.Send(Event{Kind: HookEnabled})
+}
+
+// Events returns the channel of the running source
+func (f *FakeSource) Events() <-chan Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.ev
+}
+
+// Stop stops the source, the held keys and buttons are forgotten
+func (f *FakeSource) Stop() {
+	f.mu.Lock()
+	ev, done := f.ev, f.done
+	f.ev, f.done = nil, nil
+	f.mask = 0
+	f.mu.Unlock()
+
+	if ev == nil {
+		return
+	}
+
+	close(done)
+	f.sending.Lock()
+	close(ev)
+	f.sending.Unlock()
+}
+
+// Send sends e as is, setting When if it is zero
+func (f *FakeSource) Send(e Event) error {
+	f.sending.RLock()
+	defer f.sending.RUnlock()
+
+	f.mu.Lock()
+	ev, done := f.ev, f.done
+	f.mu.Unlock()
+
+	if ev == nil {
+		return ErrSourceStopped
+	}
+	if e.When.IsZero() {
+		e.When = f.now()
+	}
+
+	select {
+	case ev <- e:
+		return nil
+	case <-done:
+		return ErrSourceStopped
+	}
+}
+
+func (f *FakeSource) now() time.Time {
+	if f.Clock != nil {
+		return f.Clock()
+	}
+	return time.Now()
+}
+
+// PressKey sends a KeyDown for every key, in order. The names are
+// the ones of ParseHotkey, a generic modifier like "ctrl" is
+// reported as its left key, like the native hook does.
+func (f *FakeSource) PressKey(names ...string) error {
+	return f.keys(KeyDown, names)
+}
+
+// ReleaseKey sends a KeyUp for every key, in order
+func (f *FakeSource) ReleaseKey(names ...string) error {
+	return f.keys(KeyUp, names)
+}
+
+func (f *FakeSource) keys(kind Kind, names []string) error {
+	codes := make([]Code, len(names))
+	for i, name := range names {
+		code, err := fakeKey(name)
+		if err != nil {
+			return err
+		}
+		codes[i] = code
+	}
+
+	for _, code := range codes {
+		f.mu.Lock()
+		m := keyModifiers[code]
+		switch {
+		case m&ModLocks != 0:
+			if kind == KeyDown {
+				f.mask ^= m
+			}
+		case kind == KeyDown:
+			f.mask |= m
+		default:
+			f.mask &^= m
+		}
+		e := Event{Kind: kind, Rawcode: uint16(code), Keychar: CharUndefined, Mask: uint16(f.mask)}
+		f.mu.Unlock()
+
+		if err := f.Send(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Tap presses the keys and buttons of a hotkey like "ctrl+c"
+// and releases them in reverse order, buttons are clicked
+// at the current pointer position
+func (f *FakeSource) Tap(hotkey string) error {
+	hk, err := ParseHotkey(hotkey)
+	if err != nil {
+		return err
+	}
+
+	if err := f.PressKey(hk.Keys...); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	x, y := f.x, f.y
+	f.mu.Unlock()
+	for _, button := range hk.Buttons {
+		switch button {
+		case "wheelUp", "wheelDown", "wheelLeft", "wheelRight":
+			err = f.scrollButton(button)
+		default:
+			err = f.Click(button, x, y)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := len(hk.Keys) - 1; i >= 0; i-- {
+		if err := f.ReleaseKey(hk.Keys[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PressButton sends a MouseDown of a button like "left" or "mleft"
+func (f *FakeSource) PressButton(name string) error {
+	return f.button(MouseDown, name)
+}
+
+// ReleaseButton sends the MouseHold the native hook
+// reports when a button is released
+func (f *FakeSource) ReleaseButton(name string) error {
+	return f.button(MouseHold, name)
+}
+
+func (f *FakeSource) button(kind Kind, name string) error {
+	button, ok := mouseButton(name)
+	if !ok || isWheelButton(Code(button)) {
+		return unknownButton(name, buttonNames())
+	}
+
+	f.mu.Lock()
+	bit := ModButton1 << (button - 1)
+	if kind == MouseDown {
+		f.mask |= bit
+	} else {
+		f.mask &^= bit
+	}
+	e := Event{Kind: kind, Button: button, Clicks: 1, X: f.x, Y: f.y, Mask: uint16(f.mask)}
+	f.mu.Unlock()
+
+	return f.Send(e)
+}
+
+// Click moves the pointer to x, y if it is elsewhere, then presses
+// and releases a button, sending MouseDown, MouseHold and MouseUp
+// like the native hook
+func (f *FakeSource) Click(name string, x, y int16) error {
+	button, ok := mouseButton(name)
+	if !ok || isWheelButton(Code(button)) {
+		return unknownButton(name, buttonNames())
+	}
+
+	f.mu.Lock()
+	moved := f.x != x || f.y != y
+	f.mu.Unlock()
+	if moved {
+		if err := f.MoveMouse(x, y); err != nil {
+			return err
+		}
+	}
+
+	if err := f.PressButton(name); err != nil {
+		return err
+	}
+	if err := f.ReleaseButton(name); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	e := Event{Kind: MouseUp, Button: button, Clicks: 1, X: x, Y: y, Mask: uint16(f.mask)}
+	f.mu.Unlock()
+
+	return f.Send(e)
+}
+
+// MoveMouse moves the pointer, sending MouseDrag
+// while a button is held and MouseMove otherwise
+func (f *FakeSource) MoveMouse(x, y int16) error {
+	f.mu.Lock()
+	f.x, f.y = x, y
+	kind := Kind(MouseMove)
+	if f.mask&ModButtons != 0 {
+		kind = MouseDrag
+	}
+	e := Event{Kind: kind, X: x, Y: y, Mask: uint16(f.mask)}
+	f.mu.Unlock()
+
+	return f.Send(e)
+}
+
+// Scroll sends a MouseWheel for each axis that is not zero,
+// dy first. Negative values scroll up and left, like Rotation.
+func (f *FakeSource) Scroll(dx, dy int32) error {
+	if dy != 0 {
+		if err := f.wheel(WheelVertical, dy); err != nil {
+			return err
+		}
+	}
+	if dx != 0 {
+		return f.wheel(WheelHorizontal, dx)
+	}
+	return nil
+}
+
+func (f *FakeSource) wheel(direction WheelDirection, rotation int32) error {
+	f.mu.Lock()
+	e := Event{
+		Kind:      MouseWheel,
+		Clicks:    1,
+		X:         f.x,
+		Y:         f.y,
+		Mask:      uint16(f.mask),
+		WheelType: WheelUnitScroll,
+		Amount:    3,
+		Rotation:  rotation,
+		Direction: uint8(direction),
+	}
+	f.mu.Unlock()
+
+	return f.Send(e)
+}
+
+// scrollButton scrolls one notch in the direction of a wheel button
+func (f *FakeSource) scrollButton(name string) error {
+	switch name {
+	case "wheelUp":
+		return f.Scroll(0, -1)
+	case "wheelDown":
+		return f.Scroll(0, 1)
+	case "wheelLeft":
+		return f.Scroll(-1, 0)
+	default:
+		return f.Scroll(1, 0)
+	}
+}
+
+// fakeKey resolves a key name of the hotkey grammar to its code,
+// the left key for a generic modifier since the native hook never
+// reports the generic ones
+func fakeKey(name string) (Code, error) {
+	key, ok := hotkeyName(name)
+	if !ok || hotkeyButtons[key] {
+		return 0, unknownKey(name, keyNames())
+	}
+
+	code, _ := keyCode(key)
+	if sides, ok := modifierSides[code]; ok {
+		code = sides[0]
+	}
+	return code, nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"fmt"
+	"time"
+)
+
+// Gesture selects how a hotkey has to be pressed
+// for a binding registered with RegisterGesture to fire
+type Gesture uint8
+
+const (
+	// Tap fires on release when the hotkey was held shorter than
+	// its timeout and no other key was pressed meanwhile
+	Tap Gesture = iota + 1
+	// LongPress fires while the hotkey is still held, once it has
+	// been held for its timeout
+	LongPress
+	// DoubleTap fires on the release of a second tap that started
+	// within its timeout after the first one ended, each tap held
+	// no longer than the timeout
+	DoubleTap
+)
+
+// Default gesture timeouts, see WithGestureTimeout
+const (
+	DefaultTapTimeout       = 200 * time.Millisecond
+	DefaultLongPressTimeout = 500 * time.Millisecond
+	DefaultDoubleTapTimeout = 300 * time.Millisecond
+)
+
+func (g Gesture) String() string {
+	switch g {
+	case Tap:
+		return "Tap"
+	case LongPress:
+		return "LongPress"
+	case DoubleTap:
+		return "DoubleTap"
+	}
+
+	return fmt.Sprintf("Gesture(%d)", uint8(g))
+}
+
+// gesture is the state of a binding registered with RegisterGesture
+type gesture struct {
+	kind    Gesture
+	keys    combination
+	timeout time.Duration
+
+	// down is set while all keys are held since downAt
+	down        bool
+	downAt      time.Time
+	downEv      Event
+	interrupted bool
+	// lastTap is the end of the first tap of a DoubleTap
+	lastTap time.Time
+
+	timer *time.Timer
+	gen   int
+}
+
+// GestureOption configures a binding registered with RegisterGesture
+type GestureOption func(*gesture)
+
+// WithGestureTimeout sets the longest Tap, the hold time of a
+// LongPress or the longest tap of a DoubleTap and pause between its two
+func WithGestureTimeout(d time.Duration) GestureOption {
+	return func(g *gesture) {
+		g.timeout = d
+	}
+}
+
+// RegisterGesture registers a gesture on the default Hook
+func RegisterGesture(kind Gesture, hotkey string, cb func(Event), opts ...GestureOption) (*Binding, error) {
+	return defaultHook.RegisterGesture(kind, hotkey, cb, opts...)
+}
+
+// RegisterGesture registers cb for a tap, long press or double tap
+// of hotkey, see ParseHotkey
+//
+// A Tap and a LongPress get the key down that completed the hotkey,
+// a Tap the key up that released it. A LongPress is called from its
+// own goroutine instead of the Process one. Gestures are matched
+// next to the other bindings, a DoubleTap does not keep the Tap of
+// the same hotkey from firing.
+func (h *Hook) RegisterGesture(kind Gesture, hotkey string, cb func(Event), opts ...GestureOption) (*Binding, error) {
+	g := &gesture{kind: kind}
+	switch kind {
+	case Tap:
+		g.timeout = DefaultTapTimeout
+	case LongPress:
+		g.timeout = DefaultLongPressTimeout
+	case DoubleTap:
+		g.timeout = DefaultDoubleTapTimeout
+	default:
+		return nil, fmt.Errorf("hook: unknown gesture %v", kind)
+	}
+
+	hk, err := ParseHotkey(hotkey)
+	if err != nil {
+		return nil, err
+	}
+	if len(hk.Buttons) != 0 {
+		return nil, fmt.Errorf("hook: gesture %q has mouse buttons, only keys are supported", hotkey)
+	}
+
+	if g.keys, _, err = h.codes(KeyDown, hk.Keys); err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	b := &Binding{hook: h, when: KeyDown, cb: cb, gesture: g}
+
+	h.stateMu.Lock()
+	h.add(b)
+	h.stateMu.Unlock()
+
+	h.log("registered %v of %v as %v\n", kind, hotkey, g.keys)
+	return b, nil
+}
+
+// advanceGestures updates the gestures with a key event and returns
+// the callbacks to call, the caller must hold stateMu
+func (h *Hook) advanceGestures(ev Event) (cbs []func(Event)) {
+	code := Code(ev.Rawcode)
+	at := eventTime(ev)
+
+	for b, g := range h.gestures {
+		member := g.keys.hasKey(code)
+
+		switch {
+		case ev.Kind == KeyDown && !g.down && member && allPressed(h.pressed, g.keys):
+			g.down = true
+			g.downAt = at
+			g.downEv = ev
+			g.interrupted = false
+			if g.kind == LongPress {
+				g.wait(h, b.cb)
+			}
+		case ev.Kind == KeyDown && !member:
+			// another key breaks taps, not long presses
+			g.interrupted = true
+			g.lastTap = time.Time{}
+		case ev.Kind == KeyUp && g.down && member:
+			g.down = false
+			g.stop()
+			if cb := g.release(at, b.cb); cb != nil {
+				h.log("calling %v of %v\n", g.kind, g.keys)
+				cbs = append(cbs, cb)
+			}
+		}
+	}
+
+	return
+}
+
+// release ends a press at the given time and returns the callback
+// to call, if any
+func (g *gesture) release(at time.Time, cb func(Event)) func(Event) {
+	held := at.Sub(g.downAt)
+
+	switch g.kind {
+	case Tap:
+		if !g.interrupted && held <= g.timeout {
+			return cb
+		}
+	case DoubleTap:
+		if g.interrupted || held > g.timeout {
+			g.lastTap = time.Time{}
+			return nil
+		}
+
+		if !g.lastTap.IsZero() && g.downAt.Sub(g.lastTap) <= g.timeout {
+			g.lastTap = time.Time{}
+			return cb
+		}
+		g.lastTap = at
+	}
+
+	return nil
+}
+
+// wait starts the LongPress timer, the caller must hold stateMu
+func (g *gesture) wait(h *Hook, cb func(Event)) {
+	g.stop()
+
+	gen := g.gen
+	ev := g.downEv
+	g.timer = time.AfterFunc(g.timeout, func() {
+		h.stateMu.Lock()
+		held := g.gen == gen && g.down
+		h.stateMu.Unlock()
+
+		if held {
+			h.log("calling %v of %v\n", g.kind, g.keys)
+			cb(ev)
+		}
+	})
+}
+
+// stop cancels a pending LongPress, the caller must hold stateMu
+func (g *gesture) stop() {
+	g.gen++
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+}
+
+// eventTime returns when ev happened, falling back
+// to now for events without a timestamp
+func eventTime(ev Event) time.Time {
+	if ev.When.IsZero() {
+		return time.Now()
+	}
+	return ev.When
+}
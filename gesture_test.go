@@ -0,0 +1,129 @@
+package hook
+
+import (
+	"testing"
+	"time"
+)
+
+// tap sends a press of key from start to end to ch
+func tap(ch chan Event, key string, start, end time.Time) {
+	ch <- Event{Rawcode: Keycode[key], Kind: KeyDown, When: start}
+	ch <- Event{Rawcode: Keycode[key], Kind: KeyUp, When: end}
+}
+
+func TestTap(t *testing.T) {
+	h := New()
+	taps := make(chan bool, 4)
+	if _, err := h.RegisterGesture(Tap, "caps_lock", func(e Event) {
+		taps <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	t0 := time.Now()
+	// counts
+	tap(ch, "caps_lock", t0, t0.Add(50*time.Millisecond))
+	// held too long
+	tap(ch, "caps_lock", t0.Add(time.Second), t0.Add(2*time.Second))
+	// interrupted by another key
+	ch <- Event{Rawcode: Keycode["caps_lock"], Kind: KeyDown, When: t0.Add(3 * time.Second)}
+	tap(ch, "a", t0.Add(3*time.Second), t0.Add(3*time.Second))
+	ch <- Event{Rawcode: Keycode["caps_lock"], Kind: KeyUp, When: t0.Add(3 * time.Second)}
+	close(ch)
+	<-done
+
+	if len(taps) != 1 {
+		t.Fatal("Expected 1 tap, got", len(taps))
+	}
+}
+
+func TestDoubleTap(t *testing.T) {
+	h := New()
+	taps := make(chan bool, 4)
+	if _, err := h.RegisterGesture(DoubleTap, "shift", func(e Event) {
+		taps <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	t0 := time.Now()
+	// counts
+	tap(ch, "shift", t0, t0.Add(50*time.Millisecond))
+	tap(ch, "shift", t0.Add(150*time.Millisecond), t0.Add(200*time.Millisecond))
+	// too far apart
+	tap(ch, "shift", t0.Add(time.Second), t0.Add(time.Second+50*time.Millisecond))
+	tap(ch, "shift", t0.Add(2*time.Second), t0.Add(2*time.Second+50*time.Millisecond))
+	close(ch)
+	<-done
+
+	if len(taps) != 1 {
+		t.Fatal("Expected 1 double tap, got", len(taps))
+	}
+}
+
+func TestDoubleTapTimeout(t *testing.T) {
+	h := New()
+	taps := make(chan bool, 4)
+	if _, err := h.RegisterGesture(DoubleTap, "shift", func(e Event) {
+		taps <- true
+	}, WithGestureTimeout(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	t0 := time.Now()
+	// both taps held longer than the default timeout
+	tap(ch, "shift", t0, t0.Add(500*time.Millisecond))
+	tap(ch, "shift", t0.Add(time.Second), t0.Add(1500*time.Millisecond))
+	// held longer than the timeout
+	tap(ch, "shift", t0.Add(3*time.Second), t0.Add(3100*time.Millisecond))
+	tap(ch, "shift", t0.Add(3200*time.Millisecond), t0.Add(4500*time.Millisecond))
+	close(ch)
+	<-done
+
+	if len(taps) != 1 {
+		t.Fatal("Expected 1 double tap, got", len(taps))
+	}
+}
+
+func TestLongPress(t *testing.T) {
+	h := New()
+	pressed := make(chan Event, 4)
+	if _, err := h.RegisterGesture(LongPress, "ctrl+a", func(e Event) {
+		pressed <- e
+	}, WithGestureTimeout(50*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	defer close(ch)
+	h.Process(ch)
+
+	// released before the timeout
+	ch <- Event{Rawcode: Keycode["ctrl"], Kind: KeyDown}
+	tap(ch, "a", time.Time{}, time.Time{})
+	select {
+	case <-pressed:
+		t.Fatal("Long press fired for a short press")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// fires while still held
+	ch <- Event{Rawcode: Keycode["a"], Kind: KeyDown}
+	select {
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for the long press")
+	case e := <-pressed:
+		if e.Kind != KeyDown || e.Rawcode != Keycode["a"] {
+			t.Fatal("Expected the key down of a, got", e)
+		}
+	}
+}
@@ -10,24 +10,11 @@
 
 package hook
 
-/*
-#cgo darwin CFLAGS: -x objective-c -Wno-deprecated-declarations
-#cgo darwin LDFLAGS: -framework Cocoa
-
-#cgo linux CFLAGS:-I/usr/src -std=gnu99
-#cgo linux LDFLAGS: -L/usr/src -lX11 -lXtst
-#cgo linux LDFLAGS: -lX11-xcb -lxcb -lxcb-xkb -lxkbcommon -lxkbcommon-x11
-//#cgo windows LDFLAGS: -lgdi32 -luser32
-
-#include "event/goEvent.h"
-*/
-import "C"
-
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
-	"unsafe"
 )
 
 const (
@@ -69,14 +56,24 @@ type DebugLevel uint8
 // If it's a Keyboard event the relevant fields are:
 // Mask, Keycode, Rawcode, and Keychar,
 // Keychar is probably what you want.
+// Modifiers decodes the Mask.
 //
 // If it's a Mouse event the relevant fields are:
-// Button, Clicks, X, Y, Amount, Rotation and Direction
+// Button, Clicks, X and Y.
+//
+// If it's a MouseWheel event they are Clicks, X, Y, WheelType,
+// Amount, Rotation and Direction, see Wheel.
 type Event struct {
-	Kind     Kind `json:"id"`
-	When     time.Time
-	Mask     uint16 `json:"mask"`
-	Reserved uint16 `json:"reserved"`
+	Kind Kind `json:"id"`
+	// When is the native timestamp of the event as wall clock time
+	When time.Time
+	// NativeTime is the raw timestamp of the platform, milliseconds
+	// since boot on windows, X server milliseconds on linux and
+	// mach_absolute_time ticks on macOS, microseconds since the epoch
+	// with the evdev source
+	NativeTime uint64 `json:"time"`
+	Mask       uint16 `json:"mask"`
+	Reserved   uint16 `json:"reserved"`
 
 	Keycode uint16 `json:"keycode"`
 	Rawcode uint16 `json:"rawcode"`
@@ -88,44 +85,123 @@ type Event struct {
 	X int16 `json:"x"`
 	Y int16 `json:"y"`
 
-	Amount    uint16 `json:"amount"`
-	Rotation  int32  `json:"rotation"`
-	Direction uint8  `json:"direction"`
+	WheelType WheelType `json:"type"`
+	Amount    uint16    `json:"amount"`
+	Rotation  int32     `json:"rotation"`
+	Direction uint8     `json:"direction"`
+
+	// Synthetic is set on the events the system reports as injected
+	// by a program, like the ones of the Post functions, so callbacks
+	// can tell them apart from the user's input
+	Synthetic bool `json:"synthetic"`
 }
 
 var (
+	lck      = sync.RWMutex{}
+	logLevel = DebugLevel(0)
+
+	defaultHook = New()
+)
+
+// Hook holds a set of bindings together with the pressed state
+// of the events it has processed
+//
+// Every Hook is independent from the others, so different parts of
+// a program can own their own bindings without stepping on each other.
+// The package level functions operate on a default Hook.
+type Hook struct {
 	/*
 		{
 			KeyDown: {
-				[0,1,2,4]: func(e Event) {
-					fmt.Println("a")
-				},
+				combination{0x11, 0x41}.key(): &Binding{},
+			},
+			MouseDown: {
+				(&Binding{codes: {0x11}, buttons: {1}}).key(): &Binding{},
 			},
 		}
 	*/
-	registry       = make(map[Kind]map[[4]Code]func(Event))
-	mouseRegistry  = make(map[Kind]map[Code]func(Event))
-	pressed        = make(map[Code]bool)
-	mousePressed   = make(map[Code]bool)
-	ev             = make(chan Event, 1024)
-	lck            = sync.RWMutex{}
-	logLevel       = DebugLevel(0)
-	lastKeyEvent   = Event{}
-	lastMouseEvent = Event{}
-)
+	registry map[Kind]map[string]*Binding
+	// index holds the bindings of registry by each of their keys, or
+	// buttons for mouse bindings, so an event only checks the
+	// combinations it is part of
+	index          map[Kind]map[Code]map[*Binding]bool
+	sequences      map[*Binding]*sequence
+	gestures       map[*Binding]*gesture
+	pressed        map[Code]bool
+	mousePressed   map[Code]bool
+	lastKeyEvent   Event
+	lastMouseEvent Event
+	// stateMu guards the registries and the pressed state
+	stateMu sync.Mutex
+
+	logLevel DebugLevel
+
+	bufferSize int
+	overflow   OverflowPolicy
+	stats      stats
+
+	source Source
+
+	// mu guards the running session
+	mu      sync.Mutex
+	session *session
+}
+
+// Option configures a Hook created by New
+type Option func(*Hook)
+
+// WithLogLevel sets the log level of a single Hook
+func WithLogLevel(level DebugLevel) Option {
+	return func(h *Hook) {
+		h.logLevel = level
+	}
+}
+
+// New returns a new Hook with no bindings
+func New(opts ...Option) *Hook {
+	h := &Hook{bufferSize: DefaultBufferSize, source: NewNativeSource()}
+	h.reset()
 
-func allPressed(pressed map[Code]bool, keys [4]Code) bool {
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *Hook) reset() {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	for _, s := range h.sequences {
+		s.reset()
+	}
+	for _, g := range h.gestures {
+		g.stop()
+	}
+
+	h.registry = make(map[Kind]map[string]*Binding)
+	h.index = make(map[Kind]map[Code]map[*Binding]bool)
+	h.sequences = make(map[*Binding]*sequence)
+	h.gestures = make(map[*Binding]*gesture)
+	h.pressed = make(map[Code]bool, 256)
+	h.mousePressed = make(map[Code]bool)
+	h.lastKeyEvent = Event{}
+	h.lastMouseEvent = Event{}
+}
+
+func allPressed(pressed map[Code]bool, keys combination) bool {
 	for _, key := range keys {
-		if key != 0 && !pressed[key] {
+		if !isPressed(pressed, key) {
 			return false
 		}
 	}
 	return true
 }
 
-func allUnpressed(pressed map[Code]bool, keys [4]Code) bool {
+func allUnpressed(pressed map[Code]bool, keys combination) bool {
 	for _, key := range keys {
-		if key != 0 && pressed[key] {
+		if isPressed(pressed, key) {
 			return false
 		}
 	}
@@ -136,153 +212,208 @@ func SetLogLevel(level DebugLevel) {
 	logLevel = level
 }
 
+// Register gohook event on the default Hook
+func Register(when Kind, cmds []string, cb func(Event), opts ...BindOption) (*Binding, error) {
+	return defaultHook.Register(when, cmds, cb, opts...)
+}
+
+// ReplaceBinding changes a binding of the default Hook
+func ReplaceBinding(b *Binding, when Kind, cmds []string, cb func(Event)) error {
+	return defaultHook.ReplaceBinding(b, when, cmds, cb)
+}
+
+// Process return go hook process of the default Hook
+func Process(evChan <-chan Event) (out chan bool) {
+	return defaultHook.Process(evChan)
+}
+
 // Register gohook event
-func Register(when Kind, cmds []string, cb func(Event)) error {
-	if len(cmds) > 4 {
-		return fmt.Errorf("too many keys. max 4")
+//
+// A combination fires on the key event of one of its keys, it may
+// have any number of keys and their order does not matter.
+// Mouse kinds take one or more buttons, like mleft and mright for a
+// chord, together with any keys that have to be held, like ctrl.
+// They fire once all buttons are down, or for MouseUp all released,
+// while the keys are held. MouseWheel takes one of wheelUp, wheelDown,
+// wheelLeft or wheelRight instead of the buttons, see WithWheelStep.
+// Unknown key or button names are reported as an *UnknownKeyError.
+// The returned Binding removes the callback again with Unregister.
+// Registering the same keys for the same kind twice replaces the
+// earlier binding. It is safe to call while Process is running.
+//
+// By default a binding fires while other modifiers are held as well,
+// see WithMatchMode and WithIgnoredModifiers to change that.
+func (h *Hook) Register(when Kind, cmds []string, cb func(Event), opts ...BindOption) (*Binding, error) {
+	codes, buttons, err := h.codes(when, cmds)
+	if err != nil {
+		return nil, err
 	}
 
-	tmp := [4]Code{}
+	b := &Binding{hook: h, when: when, codes: codes, buttons: buttons, cb: cb}
+	for _, opt := range opts {
+		opt(b)
+	}
 
-	for i, v := range cmds {
-		var code uint16
-		var ok bool
-		if when == KeyDown || when == KeyUp {
-			code, ok = WindowsVKCodes[v]
-			if !ok {
-				fmt.Printf("invalid key: %s\n", v)
-				fmt.Println("skipping...")
-				return nil
-			}
-		} else {
-			if v == "mleft" {
-				v = "left"
-			}
+	h.stateMu.Lock()
+	h.add(b)
+	h.stateMu.Unlock()
 
-			if v == "mright" {
-				v = "right"
-			}
+	h.log("registered %v as %v %v when %v\n", cmds, codes, buttons, when)
+	return b, nil
+}
 
-			if v == "mcenter" {
-				v = "center"
-			}
+// codes converts key and mouse button names to the codes of the registry,
+// unknown names are reported as an *UnknownKeyError
+//
+// Key kinds take keys only. For mouse kinds the button names win over
+// the key names, so "left" is the left button and not the arrow key.
+func (h *Hook) codes(when Kind, cmds []string) (keys, buttons combination, err error) {
+	if len(cmds) == 0 {
+		return nil, nil, fmt.Errorf("hook: no keys given")
+	}
 
-			code, ok = MouseMap[v]
+	var keyCodes, buttonCodes []Code
+	for _, v := range cmds {
+		if when == KeyDown || when == KeyUp {
+			code, ok := keyCode(v)
 			if !ok {
-				fmt.Printf("invalid mouse button: %s\n", v)
-				fmt.Println("skipping..")
-				return nil
+				return nil, nil, unknownKey(v, keyNames())
 			}
+			keyCodes = append(keyCodes, code)
+			continue
 		}
 
-		tmp[i] = Code(code)
-	}
-
-	if when == KeyDown || when == KeyUp {
-		if _, ok := registry[when]; !ok {
-			registry[when] = make(map[[4]Code]func(Event))
+		if code, ok := mouseButton(v); ok {
+			buttonCodes = append(buttonCodes, Code(code))
+			continue
 		}
-		registry[when][tmp] = cb
-	} else {
-		if _, ok := mouseRegistry[when]; !ok {
-			mouseRegistry[when] = make(map[Code]func(Event))
+		if code, ok := keyCode(v); ok {
+			keyCodes = append(keyCodes, code)
+			continue
 		}
-		mouseRegistry[when][Code(tmp[0])] = cb
+		return nil, nil, unknownButton(v, append(buttonNames(), keyNames()...))
 	}
 
-	hookLog("registered %v as %v when %v\n", cmds, tmp, when)
-	hookLog("mouseRegistry: %v\n", mouseRegistry)
-	return nil
+	if when != KeyDown && when != KeyUp && len(buttonCodes) == 0 {
+		return nil, nil, fmt.Errorf("hook: no mouse button given in %v", cmds)
+	}
+	if when == MouseWheel && (len(buttonCodes) != 1 || !isWheelButton(buttonCodes[0])) {
+		return nil, nil, fmt.Errorf("hook: %v must have exactly one of %v", cmds, wheelButtons)
+	}
+
+	return newCombination(keyCodes), newCombination(buttonCodes), nil
 }
 
 // Process return go hook process
-func Process(evChan <-chan Event) (out chan bool) {
-	out = make(chan bool)
+//
+// It calls the registered callbacks for every event of evChan
+// and sends on out once evChan is closed.
+func (h *Hook) Process(evChan <-chan Event) (out chan bool) {
+	out = make(chan bool, 1)
 	go func() {
 		for ev := range evChan {
-			hookLog("%v\n", ev)
-			if !isKeyEvent(ev) && !isMouseEvent(ev) {
-				continue
-			}
+			h.log("%v\n", ev)
 
-			if isSpam(ev) {
-				continue
+			// callbacks run without holding stateMu,
+			// so that they can change the bindings
+			for _, cb := range h.handle(ev) {
+				cb(ev)
 			}
+		}
+
+		out <- true
+	}()
 
-			updateLastEvent(ev)
+	return
+}
 
-			if isMouseEvent(ev) {
-				button := Code(ev.Button)
-				_, ok := mouseRegistry[ev.Kind][button]
-				if !ok {
-					hookLog("no callback found for %v\n", button)
-					continue
-				}
-			}
+// handle updates the pressed state with ev
+// and returns the callbacks to call for it
+func (h *Hook) handle(ev Event) (cbs []func(Event)) {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	if isWheelEvent(ev) {
+		return h.advanceWheel(ev)
+	}
 
+	if !isKeyEvent(ev) && !isMouseEvent(ev) {
+		return
+	}
+
+	if h.isSpam(ev) {
+		return
+	}
+
+	h.updateLastEvent(ev)
+
+	switch ev.Kind {
+	case KeyDown, KeyHold:
+		h.log("setting pressed[%v] = true\n", ev.Rawcode)
+		h.pressed[Code(ev.Rawcode)] = true
+	case KeyUp:
+		h.log("setting pressed[%v] = false\n", ev.Rawcode)
+		h.pressed[Code(ev.Rawcode)] = false
+	case MouseDown:
+		h.log("setting mousePressed[%v] = true\n", ev.Button)
+		h.mousePressed[Code(ev.Button)] = true
+	case MouseUp, MouseHold:
+		h.log("setting mousePressed[%v] = false\n", ev.Button)
+		h.mousePressed[Code(ev.Button)] = false
+	}
+
+	switch ev.Kind {
+	case KeyDown, KeyUp:
+		for _, b := range h.keyBindings(ev.Kind, Code(ev.Rawcode)) {
 			switch ev.Kind {
-			case KeyDown, KeyHold:
-				hookLog("setting pressed[%v] = true\n", ev.Rawcode)
-				pressed[Code(ev.Rawcode)] = true
+			case KeyDown:
+				h.log("checking if %v is pressed\n", b.codes)
+				if allPressed(h.pressed, b.codes) && b.modifiersMatch(Modifiers(ev.Mask)) {
+					h.log("calling %v\n", b.codes)
+					cbs = append(cbs, b.cb)
+				} else {
+					h.log("not all keys are pressed\n")
+				}
 			case KeyUp:
-				hookLog("setting pressed[%v] = false\n", ev.Rawcode)
-				pressed[Code(ev.Rawcode)] = false
-			case MouseDown:
-				hookLog("setting mousePressed[%v] = true\n", ev.Button)
-				mousePressed[Code(ev.Button)] = true
-			case MouseUp, MouseHold:
-				hookLog("setting mousePressed[%v] = false\n", ev.Button)
-				mousePressed[Code(ev.Button)] = false
+				h.log("checking if %v is pressed\n", b.codes)
+				if allUnpressed(h.pressed, b.codes) && b.modifiersMatch(Modifiers(ev.Mask)) {
+					h.log("calling %v\n", b.codes)
+					cbs = append(cbs, b.cb)
+				} else {
+					h.log("not all keys are pressed\n")
+				}
 			}
+		}
 
+		if ev.Kind == KeyDown {
+			cbs = append(cbs, h.advanceSequences(ev)...)
+		}
+		cbs = append(cbs, h.advanceGestures(ev)...)
+	case MouseDown, MouseUp, MouseHold:
+		button := Code(ev.Button)
+		for b := range h.index[ev.Kind][button] {
 			switch ev.Kind {
-			case KeyDown, KeyUp:
-				for combination, v := range registry[ev.Kind] {
-					switch ev.Kind {
-					case KeyDown:
-						hookLog("checking if %v is pressed\n", combination)
-						if allPressed(pressed, combination) {
-							hookLog("calling %v\n", combination)
-							v(ev)
-						} else {
-							hookLog("not all keys are pressed\n")
-						}
-					case KeyUp:
-						hookLog("checking if %v is pressed\n", combination)
-						if allUnpressed(pressed, combination) {
-							hookLog("calling %v\n", combination)
-							v(ev)
-						} else {
-							hookLog("not all keys are pressed\n")
-						}
-					}
+			case MouseDown:
+				h.log("checking if %v %v is pressed\n", b.codes, b.buttons)
+				if allPressed(h.pressed, b.codes) && allPressed(h.mousePressed, b.buttons) &&
+					b.modifiersMatch(Modifiers(ev.Mask)) {
+					h.log("calling %v %v\n", b.codes, b.buttons)
+					cbs = append(cbs, b.cb)
+				} else {
+					h.log("not all keys are pressed\n")
 				}
-			case MouseDown, MouseUp, MouseHold:
-				button := Code(ev.Button)
-				cb := mouseRegistry[ev.Kind][button]
-				switch ev.Kind {
-				case MouseDown:
-					hookLog("checking if %v is pressed\n", button)
-					if ok := mousePressed[button]; ok {
-						hookLog("calling %v\n", button)
-						cb(ev)
-					} else {
-						hookLog("not all keys are pressed\n")
-					}
-				case MouseUp, MouseHold:
-					hookLog("checking if %v is unpressed\n", button)
-					if ok := mousePressed[button]; !ok {
-						hookLog("calling %v\n", button)
-						cb(ev)
-					} else {
-						hookLog("not all keys are unpressed\n")
-					}
+			case MouseUp, MouseHold:
+				h.log("checking if %v is unpressed\n", b.buttons)
+				if allPressed(h.pressed, b.codes) && allUnpressed(h.mousePressed, b.buttons) &&
+					b.modifiersMatch(Modifiers(ev.Mask)) {
+					h.log("calling %v %v\n", b.codes, b.buttons)
+					cbs = append(cbs, b.cb)
+				} else {
+					h.log("not all keys are unpressed\n")
 				}
 			}
 		}
-
-		out <- true
-	}()
+	}
 
 	return
 }
@@ -328,53 +459,154 @@ func (e Event) String() string {
 	return "Unknown event, contact the mantainers."
 }
 
-// Start adds global event hook to OS
+// Start adds global event hook to OS for the default Hook
 // returns event channel
 func Start() chan Event {
-	ev = make(chan Event, 1024)
-	hookLog("%s\n", "starting C.start_ev")
-	go C.start_ev()
+	return defaultHook.Start()
+}
 
-	go func() {
-		for {
-			C.pollEv()
-			time.Sleep(time.Millisecond * 10)
-		}
-	}()
+// StartContext starts the default Hook until ctx is done
+func StartContext(ctx context.Context) (chan Event, error) {
+	return defaultHook.StartContext(ctx)
+}
 
-	return ev
+// Run starts and processes the default Hook until ctx is done
+func Run(ctx context.Context) error {
+	return defaultHook.Run(ctx)
 }
 
-// End removes global event hook
+// End removes global event hook of the default Hook
 func End() {
-	C.endPoll()
-	C.stop_event()
-	time.Sleep(time.Millisecond * 10)
+	defaultHook.Close()
+}
+
+// session is a single Start/Close cycle of a Hook
+type session struct {
+	ev     chan Event
+	done   chan struct{}
+	once   sync.Once
+	source Source
+
+	// queue buffers up to size events between dispatch and ev,
+	// cond is signaled whenever it changes or the session closes
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []Event
+	size    int
+	policy  OverflowPolicy
+	closed  bool
+	relay   bool
+	stats   *stats
+	pumping sync.WaitGroup
+}
+
+// Start adds global event hook to OS
+// returns event channel
+//
+// If the native hook can not be started the returned channel is
+// already closed, use StartContext to find out why.
+func (h *Hook) Start() chan Event {
+	ev, err := h.StartContext(context.Background())
+	if err != nil {
+		h.log("failed to start hook: %v\n", err)
+		ev = make(chan Event)
+		close(ev)
+	}
+
+	return ev
+}
 
-	for len(ev) != 0 {
-		<-ev
+// StartContext starts the Source of h and returns the event channel
+//
+// Once ctx is done the hook is removed and the channel is closed,
+// like a call to Close. Starting a Hook that is already running
+// returns the channel of the running hook.
+//
+// The returned error tells why the native hook could not be started,
+// for example ErrDisplayUnavailable or ErrAccessibilityDenied.
+func (h *Hook) StartContext(ctx context.Context) (chan Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	close(ev)
 
-	pressed = make(map[Code]bool, 256)
-	registry = make(map[Kind]map[[4]Code]func(Event))
-	mouseRegistry = make(map[Kind]map[Code]func(Event))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.session != nil {
+		return h.session.ev, nil
+	}
+
+	s := newSession(h.bufferSize, h.overflow, &h.stats)
+	s.source = h.source
+	if b, ok := s.source.(bufferedSource); ok {
+		b.buffer(h.bufferSize, h.overflow, &h.stats)
+	}
+	if err := s.source.Start(ctx); err != nil {
+		close(s.done)
+		s.close()
+		return nil, err
+	}
+	h.session = s
+
+	go func(events <-chan Event) {
+		for e := range events {
+			s.send(e)
+		}
+	}(s.source.Events())
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			h.stop(s)
+		case <-s.done:
+		}
+	}()
+
+	return s.ev, nil
 }
 
-// AddEvent add the block event listener
-func addEvent(key string) int {
-	cs := C.CString(key)
-	defer C.free(unsafe.Pointer(cs))
+// Run starts the hook and calls the registered callbacks
+// until ctx is done
+func (h *Hook) Run(ctx context.Context) error {
+	ev, err := h.StartContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	<-h.Process(ev)
+	return nil
+}
 
-	eve := C.add_event(cs)
-	geve := int(eve)
+// Close removes the event hook and drops every binding of h
+//
+// It is safe to call Close more than once.
+func (h *Hook) Close() {
+	h.mu.Lock()
+	s := h.session
+	h.mu.Unlock()
+
+	if s != nil {
+		h.stop(s)
+	}
 
-	return geve
+	h.reset()
 }
 
-// StopEvent stop the block event listener
-func StopEvent() {
-	C.stop_event()
+// stop ends s exactly once, closing its channel
+// after the source stopped writing to it
+func (h *Hook) stop(s *session) {
+	s.once.Do(func() {
+		close(s.done)
+		s.close()
+		s.source.Stop()
+		close(s.ev)
+
+		h.mu.Lock()
+		if h.session == s {
+			h.session = nil
+		}
+		h.mu.Unlock()
+	})
 }
 
 func hookLog(format string, args ...any) {
@@ -383,23 +615,32 @@ func hookLog(format string, args ...any) {
 	}
 }
 
-func updateLastEvent(ev Event) {
+func (h *Hook) log(format string, args ...any) {
+	if h.logLevel == Debug {
+		fmt.Printf(format, args...)
+		return
+	}
+
+	hookLog(format, args...)
+}
+
+func (h *Hook) updateLastEvent(ev Event) {
 	if isKeyEvent(ev) {
-		lastKeyEvent = ev
+		h.lastKeyEvent = ev
 	}
 
 	if isMouseEvent(ev) {
-		lastMouseEvent = ev
+		h.lastMouseEvent = ev
 	}
 }
 
-func isSpam(ev Event) bool {
+func (h *Hook) isSpam(ev Event) bool {
 	if isKeyEvent(ev) {
-		return lastKeyEvent.Rawcode == ev.Rawcode && ev.Kind == lastKeyEvent.Kind
+		return h.lastKeyEvent.Rawcode == ev.Rawcode && ev.Kind == h.lastKeyEvent.Kind
 	}
 
 	if isMouseEvent(ev) {
-		return lastMouseEvent.Button == ev.Button && ev.Kind == lastMouseEvent.Kind
+		return h.lastMouseEvent.Button == ev.Button && ev.Kind == h.lastMouseEvent.Kind
 	}
 
 	return false
@@ -412,3 +653,7 @@ func isKeyEvent(ev Event) bool {
 func isMouseEvent(ev Event) bool {
 	return ev.Kind == MouseDown || ev.Kind == MouseUp || ev.Kind == MouseHold
 }
+
+func isWheelEvent(ev Event) bool {
+	return ev.Kind == MouseWheel
+}
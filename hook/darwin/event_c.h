@@ -57,7 +57,24 @@ static inline CGEventFlags get_key_event_mask(iohook_event * const event) {
 	return native_mask;
 }
 
-static inline void post_key_event(iohook_event * const event) {
+// post_cg_event posts and releases the event, CGEventCreate* return NULL
+// when they can not create it.
+static inline int post_cg_event(CGEventSourceRef src, CGEventRef cg_event) {
+	int status = IOHOOK_FAILURE;
+	if (cg_event != NULL) {
+		CGEventPost(kCGHIDEventTap, cg_event);	// kCGSessionEventTap also works.
+		CFRelease(cg_event);
+		status = IOHOOK_SUCCESS;
+	}
+
+	if (src != NULL) {
+		CFRelease(src);
+	}
+
+	return status;
+}
+
+static inline int post_key_event(iohook_event * const event) {
 	bool is_pressed = event->type == EVENT_KEY_PRESSED;
 
 	CGEventSourceRef src = CGEventSourceCreate(kCGEventSourceStateHIDSystemState);
@@ -65,13 +82,14 @@ static inline void post_key_event(iohook_event * const event) {
 		(CGKeyCode) scancode_to_keycode(event->data.keyboard.keycode),
 		is_pressed);
 
-	CGEventSetFlags(cg_event, get_key_event_mask(event));
-	CGEventPost(kCGHIDEventTap, cg_event);	// kCGSessionEventTap also works.
-	CFRelease(cg_event);
-	CFRelease(src);
+	if (cg_event != NULL) {
+		CGEventSetFlags(cg_event, get_key_event_mask(event));
+	}
+
+	return post_cg_event(src, cg_event);
 }
 
-static inline void post_mouse_button_event(iohook_event * const event, bool is_pressed) {
+static inline int post_mouse_button_event(iohook_event * const event, bool is_pressed) {
 	CGMouseButton mouse_button;
 	CGEventType mouse_type;
 	if (event->data.mouse.button == MOUSE_BUTTON1) {
@@ -106,12 +124,10 @@ static inline void post_mouse_button_event(iohook_event * const event, bool is_p
 		),
         mouse_button
 	);
-	CGEventPost(kCGHIDEventTap, cg_event);	// kCGSessionEventTap also works.
-	CFRelease(cg_event);
-	CFRelease(src);
+	return post_cg_event(src, cg_event);
 }
 
-static inline void post_mouse_wheel_event(iohook_event * const event) {
+static inline int post_mouse_wheel_event(iohook_event * const event) {
 	// FIXME Should I create a source event with the coords?
 	// It seems to automagically use the current location of the cursor.
 	// Two options: Query the mouse, move it to x/y, scroll, then move back
@@ -125,19 +141,27 @@ static inline void post_mouse_wheel_event(iohook_event * const event) {
 		scroll_unit = kCGScrollEventUnitPixel;
 	}
 
+	// The hook reports the rotation with the opposite sign of the delta.
+	int32_t delta = -(int32_t) event->data.wheel.amount * event->data.wheel.rotation;
+
 	CGEventSourceRef src = CGEventSourceCreate(kCGEventSourceStateHIDSystemState);
-	CGEventRef cg_event = CGEventCreateScrollWheelEvent(src,
-		kCGScrollEventUnitLine,
-		// TODO Currently only support 1 wheel axis.
-		(CGWheelCount) 1, // 1 for Y-only, 2 for Y-X, 3 for Y-X-Z
-		event->data.wheel.amount * event->data.wheel.rotation);
-
-	CGEventPost(kCGHIDEventTap, cg_event);	// kCGSessionEventTap also works.
-	CFRelease(cg_event);
-	CFRelease(src);
+	CGEventRef cg_event;
+	if (event->data.wheel.direction == WHEEL_HORIZONTAL_DIRECTION) {
+		cg_event = CGEventCreateScrollWheelEvent(src,
+			kCGScrollEventUnitLine,
+			(CGWheelCount) 2, // 1 for Y-only, 2 for Y-X, 3 for Y-X-Z
+			0, delta);
+	} else {
+		cg_event = CGEventCreateScrollWheelEvent(src,
+			kCGScrollEventUnitLine,
+			(CGWheelCount) 1,
+			delta);
+	}
+
+	return post_cg_event(src, cg_event);
 }
 
-static inline void post_mouse_motion_event(iohook_event * const event) {
+static inline int post_mouse_motion_event(iohook_event * const event) {
 	CGEventSourceRef src = CGEventSourceCreate(kCGEventSourceStateHIDSystemState);
 	CGEventRef cg_event;
 	if (event->mask >> 8 == 0x00) {
@@ -179,41 +203,42 @@ static inline void post_mouse_motion_event(iohook_event * const event) {
 		);
 	}
 
-	// kCGSessionEventTap also works.
-	CGEventPost(kCGHIDEventTap, cg_event);
-	CFRelease(cg_event);
-	CFRelease(src);
+	return post_cg_event(src, cg_event);
 }
 
-IOHOOK_API void hook_post_event(iohook_event * const event) {
+IOHOOK_API int hook_post_event(iohook_event * const event) {
+	int status = IOHOOK_SUCCESS;
+
 	switch (event->type) {
 		case EVENT_KEY_PRESSED:
 		case EVENT_KEY_RELEASED:
-			post_key_event(event);
+			status = post_key_event(event);
 			break;
 
 
 		case EVENT_MOUSE_PRESSED:
-			post_mouse_button_event(event, true);
+			status = post_mouse_button_event(event, true);
 			break;
 
 		case EVENT_MOUSE_RELEASED:
-			post_mouse_button_event(event, false);
+			status = post_mouse_button_event(event, false);
 			break;
 
 		case EVENT_MOUSE_CLICKED:
-			post_mouse_button_event(event, true);
-			post_mouse_button_event(event, false);
+			status = post_mouse_button_event(event, true);
+			if (status == IOHOOK_SUCCESS) {
+				status = post_mouse_button_event(event, false);
+			}
 			break;
 
 		case EVENT_MOUSE_WHEEL:
-            post_mouse_wheel_event(event);
+			status = post_mouse_wheel_event(event);
 			break;
 
 
 		case EVENT_MOUSE_MOVED:
 		case EVENT_MOUSE_DRAGGED:
-			post_mouse_motion_event(event);
+			status = post_mouse_motion_event(event);
 			break;
 
 
@@ -230,4 +255,6 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 					__FUNCTION__, __LINE__, event->type);
 			break;
 	}
+
+	return status;
 }
@@ -317,6 +317,7 @@ static void hook_status_proc(CFRunLoopObserverRef observer, CFRunLoopActivity ac
 
 			event.type = EVENT_HOOK_ENABLED;
 			event.mask = 0x00;
+			event.injected = false;
 
 			// Fire the hook start event.
 			dispatch_event(&event);
@@ -329,6 +330,7 @@ static void hook_status_proc(CFRunLoopObserverRef observer, CFRunLoopActivity ac
 
 			event.type = EVENT_HOOK_DISABLED;
 			event.mask = 0x00;
+			event.injected = false;
 
 			// Fire the hook stop event.
 			dispatch_event(&event);
@@ -933,6 +935,9 @@ CGEventRef hook_event_proc(CGEventTapProxy tap_proxy, CGEventType type, CGEventR
 	// Grab the native event timestap for use later..
 	uint64_t timestamp = (uint64_t) CGEventGetTimestamp(event_ref);
 
+	// Events of the devices have no source process, posted ones the poster.
+	event.injected = CGEventGetIntegerValueField(event_ref, kCGEventSourceUnixProcessID) != 0;
+
 	// Get the event class.
 	switch (type) {
 		case kCGEventKeyDown:
@@ -113,6 +113,9 @@ typedef struct _iohook_event {
 	uint64_t time;
 	uint16_t mask;
 	uint16_t reserved;
+	// injected is set on the events a program synthesized
+	// instead of a device, like the ones of hook_post_event.
+	bool injected;
 	union {
 		keyboard_event_data keyboard;
 		mouse_event_data mouse;
@@ -410,8 +413,9 @@ extern "C" {
 	// Set the logger callback functions.
 	IOHOOK_API void hook_set_logger_proc(logger_t logger_proc);
 
-	// Send a virtual event back to the system.
-	IOHOOK_API void hook_post_event(iohook_event * const event);
+	// Send a virtual event back to the system, returns IOHOOK_SUCCESS
+	// or IOHOOK_FAILURE if the system refused it.
+	IOHOOK_API int hook_post_event(iohook_event * const event);
 
 	// Set the event callback function.
 	IOHOOK_API void hook_set_dispatch_proc(dispatcher_t dispatch_proc);
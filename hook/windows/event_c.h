@@ -41,6 +41,10 @@
 #define KEYEVENTF_KEYDOWN		0x0000
 #endif
 
+#ifndef MOUSEEVENTF_HWHEEL
+#define MOUSEEVENTF_HWHEEL		0x1000
+#endif
+
 #define MAX_WINDOWS_COORD_VALUE 65535
 
 static UINT keymask_lookup[8] = {
@@ -55,13 +59,18 @@ static UINT keymask_lookup[8] = {
 	VK_RMENU
 };
 
-IOHOOK_API void hook_post_event(iohook_event * const event) {
+IOHOOK_API int hook_post_event(iohook_event * const event) {
+	int status = IOHOOK_SUCCESS;
+
 	//FIXME implement multiple monitor support
 	uint16_t screen_width   = GetSystemMetrics( SM_CXSCREEN );
 	uint16_t screen_height  = GetSystemMetrics( SM_CYSCREEN );
 
 	unsigned char events_size = 0, events_max = 28;
 	INPUT *events = malloc(sizeof(INPUT) * events_max);
+	if (events == NULL) {
+		return IOHOOK_ERROR_OUT_OF_MEMORY;
+	}
 
 	if (event->mask & (MASK_SHIFT | MASK_CTRL | MASK_META | MASK_ALT)) {
 		unsigned int i;
@@ -122,6 +131,7 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 				logger(LOG_LEVEL_INFO, "%s [%u]: Unable to lookup scancode: %li\n",
 						__FUNCTION__, __LINE__,
 						event->data.keyboard.keycode);
+				status = IOHOOK_FAILURE;
 			}
 			break;
 
@@ -138,6 +148,7 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 				logger(LOG_LEVEL_INFO, "%s [%u]: Unable to lookup scancode: %li\n",
 						__FUNCTION__, __LINE__,
 						event->data.keyboard.keycode);
+				status = IOHOOK_FAILURE;
 			}
 			break;
 
@@ -226,10 +237,14 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 
 		case EVENT_MOUSE_WHEEL:
 			events[events_size].type = INPUT_MOUSE;
-			events[events_size].mi.dwFlags = MOUSEEVENTF_WHEEL;
+			if (event->data.wheel.direction == WHEEL_HORIZONTAL_DIRECTION) {
+				events[events_size].mi.dwFlags = MOUSEEVENTF_HWHEEL;
+			} else {
+				events[events_size].mi.dwFlags = MOUSEEVENTF_WHEEL;
+			}
 
-			// type, amount and rotation?
-			events[events_size].mi.mouseData = event->data.wheel.amount * event->data.wheel.rotation * WHEEL_DELTA;
+			// The hook reports the rotation with the opposite sign of mouseData.
+			events[events_size].mi.mouseData = -event->data.wheel.amount * event->data.wheel.rotation * WHEEL_DELTA;
 
 			events[events_size].mi.dx = event->data.wheel.x * (MAX_WINDOWS_COORD_VALUE / screen_width) + 1;
 			events[events_size].mi.dy = event->data.wheel.y * (MAX_WINDOWS_COORD_VALUE / screen_height) + 1;
@@ -324,10 +339,15 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 	// memcpy(key_events + 1, key_events, sizeof(INPUT));
 	// key_events[1].ki.dwFlags |= KEYEVENTF_KEYUP;
 
-	if (! SendInput(events_size, events, sizeof(INPUT)) ) {
+	// SendInput returns the number of events it inserted, fewer means
+	// another thread blocked the input or UIPI refused it.
+	if (SendInput(events_size, events, sizeof(INPUT)) != events_size) {
 		logger(LOG_LEVEL_ERROR, "%s [%u]: SendInput() failed! (%#lX)\n",
 				__FUNCTION__, __LINE__, (unsigned long) GetLastError());
+		status = IOHOOK_FAILURE;
 	}
 
 	free(events);
+
+	return status;
 }
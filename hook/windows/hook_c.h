@@ -165,6 +165,7 @@ void hook_start_proc() {
 
 	event.type = EVENT_HOOK_ENABLED;
 	event.mask = 0x00;
+	event.injected = false;
 
 	// Fire the hook start event.
 	dispatch_event(&event);
@@ -180,6 +181,7 @@ void hook_stop_proc() {
 
 	event.type = EVENT_HOOK_DISABLED;
 	event.mask = 0x00;
+	event.injected = false;
 
 	// Fire the hook stop event.
 	dispatch_event(&event);
@@ -279,6 +281,7 @@ static void process_key_released(KBDLLHOOKSTRUCT *kbhook) {
 
 LRESULT CALLBACK keyboard_hook_event_proc(int nCode, WPARAM wParam, LPARAM lParam) {
 	KBDLLHOOKSTRUCT *kbhook = (KBDLLHOOKSTRUCT *) lParam;
+	event.injected = (kbhook->flags & LLKHF_INJECTED) != 0;
 	switch (wParam) {
 		case WM_KEYDOWN:
 		case WM_SYSKEYDOWN:
@@ -491,6 +494,7 @@ static void process_mouse_wheel(MSLLHOOKSTRUCT *mshook, uint8_t direction) {
 
 LRESULT CALLBACK mouse_hook_event_proc(int nCode, WPARAM wParam, LPARAM lParam) {
 	MSLLHOOKSTRUCT *mshook = (MSLLHOOKSTRUCT *) lParam;
+	event.injected = (mshook->flags & LLMHF_INJECTED) != 0;
 	switch (wParam) {
 		case WM_LBUTTONDOWN:
 			set_modifier_mask(MASK_BUTTON1);
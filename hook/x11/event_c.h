@@ -66,21 +66,27 @@ static unsigned int convert_to_native_mask(unsigned int mask) {
 }
 #endif
 
-static inline void post_key_event(iohook_event * const event) {
+static inline int post_key_event(iohook_event * const event) {
+	int status = IOHOOK_SUCCESS;
+
 	#ifdef USE_XTEST
 	// FIXME Currently ignoring EVENT_KEY_TYPED.
 	if (event->type == EVENT_KEY_PRESSED) {
-		XTestFakeKeyEvent(
-			properties_disp,
-			scancode_to_keycode(event->data.keyboard.keycode),
-			True,
-			0);
+		if (!XTestFakeKeyEvent(
+				properties_disp,
+				scancode_to_keycode(event->data.keyboard.keycode),
+				True,
+				0)) {
+			status = IOHOOK_FAILURE;
+		}
 	} else if (event->type == EVENT_KEY_RELEASED) {
-		XTestFakeKeyEvent(
-			properties_disp,
-			scancode_to_keycode(event->data.keyboard.keycode),
-			False,
-			0);
+		if (!XTestFakeKeyEvent(
+				properties_disp,
+				scancode_to_keycode(event->data.keyboard.keycode),
+				False,
+				0)) {
+			status = IOHOOK_FAILURE;
+		}
 	}
 	#else
 	XKeyEvent key_event;
@@ -109,15 +115,23 @@ static inline void post_key_event(iohook_event * const event) {
 	// FIXME Currently ignoring typed events.
 	if (event->type == EVENT_KEY_PRESSED) {
 		key_event.type = KeyPress;
-		XSendEvent(properties_disp, InputFocus, False, KeyPressMask, (XEvent *) &key_event);
+		if (!XSendEvent(properties_disp, InputFocus, False, KeyPressMask, (XEvent *) &key_event)) {
+			status = IOHOOK_FAILURE;
+		}
 	} else if (event->type == EVENT_KEY_RELEASED) {
 		key_event.type = KeyRelease;
-		XSendEvent(properties_disp, InputFocus, False, KeyReleaseMask, (XEvent *) &key_event);
+		if (!XSendEvent(properties_disp, InputFocus, False, KeyReleaseMask, (XEvent *) &key_event)) {
+			status = IOHOOK_FAILURE;
+		}
 	}
 	#endif
+
+	return status;
 }
 
-static inline void post_mouse_button_event(iohook_event * const event) {
+static inline int post_mouse_button_event(iohook_event * const event) {
+	int status = IOHOOK_SUCCESS;
+
 	#ifdef USE_XTEST
 	Window ret_root;
 	Window ret_child;
@@ -132,7 +146,9 @@ static inline void post_mouse_button_event(iohook_event * const event) {
 	if (query_status) {
 		if (event->data.mouse.x != root_x || event->data.mouse.y != root_y) {
 			// Move the pointer to the specified position.
-			XTestFakeMotionEvent(properties_disp, -1, event->data.mouse.x, event->data.mouse.y, 0);
+			if (!XTestFakeMotionEvent(properties_disp, -1, event->data.mouse.x, event->data.mouse.y, 0)) {
+				status = IOHOOK_FAILURE;
+			}
 		} else {
 			query_status = False;
 		}
@@ -142,19 +158,29 @@ static inline void post_mouse_button_event(iohook_event * const event) {
 		// Wheel events should be the same as click events on X11.
 		// type, amount and rotation
 		if (event->data.wheel.rotation < 0) {
-			XTestFakeButtonEvent(properties_disp, WheelUp, True, 0);
-			XTestFakeButtonEvent(properties_disp, WheelUp, False, 0);
+			if (!XTestFakeButtonEvent(properties_disp, WheelUp, True, 0) ||
+					!XTestFakeButtonEvent(properties_disp, WheelUp, False, 0)) {
+				status = IOHOOK_FAILURE;
+			}
 		} else {
-			XTestFakeButtonEvent(properties_disp, WheelDown, True, 0);
-			XTestFakeButtonEvent(properties_disp, WheelDown, False, 0);
+			if (!XTestFakeButtonEvent(properties_disp, WheelDown, True, 0) ||
+					!XTestFakeButtonEvent(properties_disp, WheelDown, False, 0)) {
+				status = IOHOOK_FAILURE;
+			}
 		}
 	} else if (event->type == EVENT_MOUSE_PRESSED) {
-		XTestFakeButtonEvent(properties_disp, event->data.mouse.button, True, 0);
+		if (!XTestFakeButtonEvent(properties_disp, event->data.mouse.button, True, 0)) {
+			status = IOHOOK_FAILURE;
+		}
 	} else if (event->type == EVENT_MOUSE_RELEASED) {
-		XTestFakeButtonEvent(properties_disp, event->data.mouse.button, False, 0);
+		if (!XTestFakeButtonEvent(properties_disp, event->data.mouse.button, False, 0)) {
+			status = IOHOOK_FAILURE;
+		}
 	} else if (event->type == EVENT_MOUSE_CLICKED) {
-		XTestFakeButtonEvent(properties_disp, event->data.mouse.button, True, 0);
-		XTestFakeButtonEvent(properties_disp, event->data.mouse.button, False, 0);
+		if (!XTestFakeButtonEvent(properties_disp, event->data.mouse.button, True, 0) ||
+				!XTestFakeButtonEvent(properties_disp, event->data.mouse.button, False, 0)) {
+			status = IOHOOK_FAILURE;
+		}
 	}
 
 	if (query_status) {
@@ -216,19 +242,29 @@ static inline void post_mouse_button_event(iohook_event * const event) {
 	if (event->type != EVENT_MOUSE_RELEASED) {
 		// FIXME Where do we set event->button?
 		btn_event.type = ButtonPress;
-		XSendEvent(properties_disp, InputFocus, False, ButtonPressMask, (XEvent *) &btn_event);
+		if (!XSendEvent(properties_disp, InputFocus, False, ButtonPressMask, (XEvent *) &btn_event)) {
+			status = IOHOOK_FAILURE;
+		}
 	}
 
 	if (event->type != EVENT_MOUSE_PRESSED) {
 		btn_event.type = ButtonRelease;
-		XSendEvent(properties_disp, InputFocus, False, ButtonReleaseMask, (XEvent *) &btn_event);
+		if (!XSendEvent(properties_disp, InputFocus, False, ButtonReleaseMask, (XEvent *) &btn_event)) {
+			status = IOHOOK_FAILURE;
+		}
 	}
 	#endif
+
+	return status;
 }
 
-static inline void post_mouse_motion_event(iohook_event * const event) {
+static inline int post_mouse_motion_event(iohook_event * const event) {
+	int status = IOHOOK_SUCCESS;
+
     #ifdef USE_XTEST
-	XTestFakeMotionEvent(properties_disp, -1, event->data.mouse.x, event->data.mouse.y, 0);
+	if (!XTestFakeMotionEvent(properties_disp, -1, event->data.mouse.x, event->data.mouse.y, 0)) {
+		status = IOHOOK_FAILURE;
+	}
     #else
 	XMotionEvent mov_event;
 
@@ -308,11 +344,17 @@ static inline void post_mouse_motion_event(iohook_event * const event) {
 	}
 
 	// NOTE x_mask = NoEventMask.
-	XSendEvent(properties_disp, InputFocus, False, event_mask, (XEvent *) &mov_event);
+	if (!XSendEvent(properties_disp, InputFocus, False, event_mask, (XEvent *) &mov_event)) {
+		status = IOHOOK_FAILURE;
+	}
     #endif
+
+	return status;
 }
 
-IOHOOK_API void hook_post_event(iohook_event * const event) {
+IOHOOK_API int hook_post_event(iohook_event * const event) {
+	int status = IOHOOK_SUCCESS;
+
 	XLockDisplay(properties_disp);
 
 	#ifdef USE_XTEST
@@ -325,7 +367,6 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 		}
 	}
 
-	unsigned int i;
 	for (i = 0; i < sizeof(btnmask_lookup) / sizeof(unsigned int); i++) {
 		if (event->mask & btnmask_lookup[i]) {
 			XTestFakeButtonEvent(properties_disp, i + 1, True, 0);
@@ -337,19 +378,19 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 		case EVENT_KEY_PRESSED:
 		case EVENT_KEY_RELEASED:
 		case EVENT_KEY_TYPED:
-			post_key_event(event);
+			status = post_key_event(event);
 			break;
 
 		case EVENT_MOUSE_PRESSED:
 		case EVENT_MOUSE_RELEASED:
 		case EVENT_MOUSE_WHEEL:
 		case EVENT_MOUSE_CLICKED:
-			post_mouse_button_event(event);
+			status = post_mouse_button_event(event);
 			break;
 
 		case EVENT_MOUSE_DRAGGED:
 		case EVENT_MOUSE_MOVED:
-			post_mouse_motion_event(event);
+			status = post_mouse_motion_event(event);
 			break;
 
 		case EVENT_HOOK_ENABLED:
@@ -365,13 +406,11 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 
 	#ifdef USE_XTEST
 	// Release the previously held modifier keys used to fake the event mask.
-	unsigned int i ;
-	for (i= 0; i < sizeof(keymask_lookup) / sizeof(KeySym); i++) {
+	for (i = 0; i < sizeof(keymask_lookup) / sizeof(KeySym); i++) {
 		if (event->mask & 1 << i) {
 			XTestFakeKeyEvent(properties_disp, XKeysymToKeycode(properties_disp, keymask_lookup[i]), False, 0);
 		}
 	}
-	unsigned int i;
 	for (i = 0; i < sizeof(btnmask_lookup) / sizeof(unsigned int); i++) {
 		if (event->mask & btnmask_lookup[i]) {
 			XTestFakeButtonEvent(properties_disp, i + 1, False, 0);
@@ -382,4 +421,6 @@ IOHOOK_API void hook_post_event(iohook_event * const event) {
 	// Don't forget to flush!
 	XSync(properties_disp, True);
 	XUnlockDisplay(properties_disp);
+
+	return status;
 }
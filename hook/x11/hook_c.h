@@ -95,6 +95,19 @@ static struct xkb_state *state = NULL;
 // Virtual event pointer.
 static iohook_event event;
 
+// The minor opcode of the XTEST FakeInput request.
+#define XTEST_FAKE_INPUT 2
+
+// The XTEST major opcode, and the device event the last recorded
+// FakeInput request asked for. The server turns the request into
+// the event of the XTest device right away, so it comes next.
+static int xtest_major = 0;
+static struct _fake_input {
+	bool pending;
+	uint8_t type;
+	uint8_t detail;
+} fake_input;
+
 // Event dispatch callback.
 static dispatcher_t dispatcher = NULL;
 
@@ -258,6 +271,7 @@ void hook_event_proc(XPointer closeure, XRecordInterceptData *recorded_data) {
 
 		event.type = EVENT_HOOK_ENABLED;
 		event.mask = 0x00;
+		event.injected = false;
 
 		// Fire the hook start event.
 		dispatch_event(&event);
@@ -268,13 +282,27 @@ void hook_event_proc(XPointer closeure, XRecordInterceptData *recorded_data) {
 
 		event.type = EVENT_HOOK_DISABLED;
 		event.mask = 0x00;
+		event.injected = false;
 
 		// Fire the hook stop event.
 		dispatch_event(&event);
-	} else if (recorded_data->category == XRecordFromServer || recorded_data->category == XRecordFromClient) {
+	} else if (recorded_data->category == XRecordFromClient) {
+		// Only the XTEST FakeInput requests are recorded.
+		unsigned char *req = (unsigned char *) recorded_data->data;
+		if (recorded_data->data_len >= 2 && req[0] == xtest_major && req[1] == XTEST_FAKE_INPUT) {
+			fake_input.pending = true;
+			fake_input.type = req[4];
+			fake_input.detail = req[5];
+		}
+	} else if (recorded_data->category == XRecordFromServer) {
 		// Get XRecord data.
 		XRecordDatum *data = (XRecordDatum *) recorded_data->data;
 
+		// The motion of a FakeInput request has a flag for its detail.
+		event.injected = fake_input.pending && data->type == fake_input.type
+				&& (data->type == MotionNotify || data->event.u.u.detail == fake_input.detail);
+		fake_input.pending = false;
+
 		if (data->type == KeyPress) {
 			// The X11 KeyCode associated with this event.
 			KeyCode keycode = (KeyCode) data->event.u.u.detail;
@@ -881,6 +909,19 @@ static int xrecord_alloc() {
 		hook->data.range->device_events.first = KeyPress;
 		hook->data.range->device_events.last = MotionNotify;
 
+		// Record the XTEST FakeInput requests to tell the events they
+		// synthesize from the ones of the devices.
+		int xtest_event, xtest_error;
+		fake_input.pending = false;
+		if (XQueryExtension(hook->data.display, "XTEST", &xtest_major, &xtest_event, &xtest_error)) {
+			hook->data.range->ext_requests.ext_major.first = xtest_major;
+			hook->data.range->ext_requests.ext_major.last = xtest_major;
+			hook->data.range->ext_requests.ext_minor.first = XTEST_FAKE_INPUT;
+			hook->data.range->ext_requests.ext_minor.last = XTEST_FAKE_INPUT;
+		} else {
+			xtest_major = 0;
+		}
+
 		// Note that the documentation for this function is incorrect,
 		// hook->data.display should be used!
 		// See: http://www.x.org/releases/X11R7.6/doc/libXtst/recordlib.txt
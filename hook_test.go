@@ -1,6 +1,10 @@
 package hook
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -9,24 +13,38 @@ const (
 	TIMEOUT = 2 * time.Second
 )
 
+// fakeDefault makes the default hook read from
+// a FakeSource until the test ends
+func fakeDefault(t *testing.T) *FakeSource {
+	t.Helper()
+
+	src := NewFakeSource()
+	if err := SetSource(src); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetSource(nil)
+	})
+
+	return src
+}
+
 func TestKeyDown(t *testing.T) {
 	done := make(chan bool)
-	err := Register(KeyDown, []string{"a"}, func(e Event) {
+	_, err := Register(KeyDown, []string{"a"}, func(e Event) {
 		done <- true
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	src := fakeDefault(t)
 	ch := Start()
 	defer End()
 	Process(ch)
 
-	go func() {
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyDown,
-		}
-	}()
+	if err := src.PressKey("a"); err != nil {
+		t.Fatal(err)
+	}
 
 	select {
 	case <-time.After(TIMEOUT):
@@ -38,27 +56,20 @@ func TestKeyDown(t *testing.T) {
 
 func TestKeyDownWithModifier(t *testing.T) {
 	done := make(chan bool)
-	err := Register(KeyDown, []string{"ctrl", "a"}, func(e Event) {
+	_, err := Register(KeyDown, []string{"ctrl", "a"}, func(e Event) {
 		done <- true
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	src := fakeDefault(t)
 	ch := Start()
 	defer End()
 	Process(ch)
 
-	go func() {
-		ch <- Event{
-			Rawcode: Keycode["ctrl"],
-			Kind:    KeyDown,
-		}
-		time.Sleep(100 * time.Millisecond)
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyDown,
-		}
-	}()
+	if err := src.PressKey("ctrl", "a"); err != nil {
+		t.Fatal(err)
+	}
 
 	select {
 	case <-time.After(TIMEOUT):
@@ -70,22 +81,20 @@ func TestKeyDownWithModifier(t *testing.T) {
 
 func TestKeyUp(t *testing.T) {
 	done := make(chan bool)
-	err := Register(KeyUp, []string{"delete"}, func(e Event) {
+	_, err := Register(KeyUp, []string{"delete"}, func(e Event) {
 		done <- true
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	src := fakeDefault(t)
 	ch := Start()
 	defer End()
 	Process(ch)
 
-	go func() {
-		ch <- Event{
-			Rawcode: Keycode["delete"],
-			Kind:    KeyUp,
-		}
-	}()
+	if err := src.ReleaseKey("delete"); err != nil {
+		t.Fatal(err)
+	}
 
 	select {
 	case <-time.After(TIMEOUT):
@@ -96,27 +105,21 @@ func TestKeyUp(t *testing.T) {
 }
 
 func TestKeyUpWithModifier(t *testing.T) {
-	done := make(chan bool)
-	err := Register(KeyUp, []string{"ctrl", "a"}, func(e Event) {
+	done := make(chan bool, 1)
+	_, err := Register(KeyUp, []string{"ctrl", "a"}, func(e Event) {
 		done <- true
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	src := fakeDefault(t)
 	ch := Start()
 	defer End()
 	Process(ch)
 
-	go func() {
-		ch <- Event{
-			Rawcode: Keycode["ctrl"],
-			Kind:    KeyUp,
-		}
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyUp,
-		}
-	}()
+	if err := src.ReleaseKey("ctrl", "a"); err != nil {
+		t.Fatal(err)
+	}
 
 	select {
 	case <-time.After(TIMEOUT):
@@ -128,22 +131,20 @@ func TestKeyUpWithModifier(t *testing.T) {
 
 func TestMouseDown(t *testing.T) {
 	done := make(chan bool)
-	err := Register(MouseDown, []string{"mleft"}, func(e Event) {
+	_, err := Register(MouseDown, []string{"mleft"}, func(e Event) {
 		done <- true
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	src := fakeDefault(t)
 	ch := Start()
 	defer End()
 	Process(ch)
 
-	go func() {
-		ch <- Event{
-			Button: MouseMap["left"],
-			Kind:   MouseDown,
-		}
-	}()
+	if err := src.PressButton("left"); err != nil {
+		t.Fatal(err)
+	}
 
 	select {
 	case <-time.After(TIMEOUT):
@@ -155,22 +156,20 @@ func TestMouseDown(t *testing.T) {
 
 func TestMouseUp(t *testing.T) {
 	done := make(chan bool)
-	err := Register(MouseUp, []string{"mright"}, func(e Event) {
+	_, err := Register(MouseUp, []string{"mright"}, func(e Event) {
 		done <- true
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	src := fakeDefault(t)
 	ch := Start()
 	defer End()
 	Process(ch)
 
-	go func() {
-		ch <- Event{
-			Button: MouseMap["right"],
-			Kind:   MouseUp,
-		}
-	}()
+	if err := src.Click("right", 10, 20); err != nil {
+		t.Fatal(err)
+	}
 
 	select {
 	case <-time.After(TIMEOUT):
@@ -181,56 +180,113 @@ func TestMouseUp(t *testing.T) {
 }
 
 func TestCombinationsLimit(t *testing.T) {
-	// Should fail if more than 4 keys are provided
-	err := Register(KeyDown, []string{"ctrl", "a", "b", "c", "d"}, func(e Event) {})
+	// More than 4 keys used to be rejected, combinations have no limit now
+	_, err := Register(KeyDown, []string{"ctrl", "a", "b", "c", "d"}, func(e Event) {})
 	if err != nil {
-		t.Log("That was expected")
-	} else {
-		t.Fatal("Expected error, got none")
+		t.Fatal("Expected no error for 5 keys, got", err)
 	}
 
 	// Should succeed if less than 4 keys are provided
-	err = Register(KeyDown, []string{"ctrl", "a", "b", "c"}, func(e Event) {})
+	_, err = Register(KeyDown, []string{"ctrl", "a", "b", "c"}, func(e Event) {})
 	if err != nil {
 		t.Fatal("Expected no error, got", err)
 	}
 }
 
+func TestLargeCombinations(t *testing.T) {
+	h := New()
+	keys := []string{"ctrl", "shift", "alt", "a", "s", "d", "f", "j", "k", "l"}
+	done := make(chan bool, 1)
+	if _, err := h.Register(KeyDown, keys, func(e Event) {
+		done <- true
+	}); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	// a subset of the keys does not fire
+	if _, err := h.Register(KeyDown, keys[:5], func(e Event) {}); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	ch := make(chan Event)
+	defer close(ch)
+	h.Process(ch)
+
+	for _, key := range keys {
+		ch <- Event{Rawcode: Keycode[key], Kind: KeyDown}
+	}
+
+	select {
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for the combination")
+	case <-done:
+	}
+}
+
+func TestCombinationOrder(t *testing.T) {
+	h := New()
+	calls := make(chan string, 2)
+	if _, err := h.Register(KeyDown, []string{"ctrl", "a"}, func(e Event) {
+		calls <- "first"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// the same keys in another order replace the first binding
+	if _, err := h.Register(KeyDown, []string{"a", "ctrl", "a"}, func(e Event) {
+		calls <- "second"
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+	ch <- Event{Rawcode: Keycode["ctrl"], Kind: KeyDown}
+	ch <- Event{Rawcode: Keycode["a"], Kind: KeyDown}
+	// not part of the combination
+	ch <- Event{Rawcode: Keycode["b"], Kind: KeyDown}
+	close(ch)
+	<-done
+	close(calls)
+
+	got := []string{}
+	for call := range calls {
+		got = append(got, call)
+	}
+	if len(got) != 1 || got[0] != "second" {
+		t.Fatal("Expected only the second binding, got", got)
+	}
+}
+
 func TestMouseDownWithMouseUp(t *testing.T) {
-	mouseDownOccurred := false
+	var mouseDownOccurred atomic.Bool
 	mouseUpOccurred := make(chan bool)
-	err := Register(MouseDown, []string{"mleft"}, func(e Event) {
-		mouseDownOccurred = true
+	_, err := Register(MouseDown, []string{"mleft"}, func(e Event) {
+		mouseDownOccurred.Store(true)
 	})
 	if err != nil {
 		t.Fatal("Could not register mouse down callback: ", err)
 	}
-	err = Register(MouseUp, []string{"mleft"}, func(e Event) {
+	_, err = Register(MouseUp, []string{"mleft"}, func(e Event) {
 		mouseUpOccurred <- true
 	})
 	if err != nil {
 		t.Fatal("Could not register mouse up callback: ", err)
 	}
+	src := fakeDefault(t)
 	ch := Start()
-
 	defer End()
-
 	Process(ch)
 
-	go func() {
-		ch <- Event{
-			Button: MouseMap["left"],
-			Kind:   MouseDown,
-		}
-		ch <- Event{
-			Button: MouseMap["right"],
-			Kind:   MouseUp,
-		}
-	}()
+	if err := src.PressButton("left"); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Click("right", 0, 0); err != nil {
+		t.Fatal(err)
+	}
 
 	select {
 	case <-time.After(time.Second * 1):
-		if !mouseDownOccurred {
+		if !mouseDownOccurred.Load() {
 			t.Fatal("Timeout waiting for mouse events")
 		}
 	case <-mouseUpOccurred:
@@ -239,76 +295,418 @@ func TestMouseDownWithMouseUp(t *testing.T) {
 }
 
 func TestPreventKeyDownSpamming(t *testing.T) {
-	count := 0
-	err := Register(KeyDown, []string{"a"}, func(e Event) {
-		count++
+	var count atomic.Int32
+	_, err := Register(KeyDown, []string{"a"}, func(e Event) {
+		count.Add(1)
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	src := fakeDefault(t)
 	ch := Start()
 	defer End()
 	Process(ch)
 
-	go func() {
+	steps := []func() error{
 		// counts
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyDown,
-		}
+		func() error { return src.PressKey("a") },
 		// doesn't count
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyDown,
-		}
+		func() error { return src.PressKey("a") },
 		// doesn't count
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyDown,
-		}
+		func() error { return src.PressKey("a") },
 		// triggering key up for a different key
 		// should clear the buffer
-		ch <- Event{
-			Rawcode: Keycode["b"],
-			Kind:    KeyUp,
-		}
+		func() error { return src.ReleaseKey("b") },
 		// counts
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyDown,
-		}
+		func() error { return src.PressKey("a") },
 		// triggering a mouse event should not clear the buffer
-		ch <- Event{
-			Button: MouseMap["left"],
-			Kind:   MouseDown,
-		}
+		func() error { return src.PressButton("left") },
 		// doesn't count
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyDown,
-		}
+		func() error { return src.PressKey("a") },
 		// triggering key up for the same key
 		// should clear the buffer
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyUp,
-		}
+		func() error { return src.ReleaseKey("a") },
 		// counts
-		ch <- Event{
-			Rawcode: Keycode["a"],
-			Kind:    KeyDown,
+		func() error { return src.PressKey("a") },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
 		}
+	}
 
-		// Total count should be 3
-	}()
+	// Total count should be 3
 
 	select {
 	case <-time.After(TIMEOUT):
 		t.Fatal("Timeout waiting for keydown")
 	case <-time.After(100 * time.Millisecond):
-		if count != 3 {
-			t.Fatal("Expected 3 keydowns, got", count)
+		if count.Load() != 3 {
+			t.Fatal("Expected 3 keydowns, got", count.Load())
 		}
 		t.Log("KeyDown received")
 	}
 }
+
+func TestHookInstancesAreIsolated(t *testing.T) {
+	first, second := New(), New()
+	secondDone := make(chan bool, 1)
+
+	if _, err := first.Register(KeyDown, []string{"a"}, func(e Event) {}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Register(KeyDown, []string{"b"}, func(e Event) {
+		secondDone <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// closing one hook must not drop the bindings of the other
+	first.Close()
+
+	ch := make(chan Event)
+	defer close(ch)
+	second.Process(ch)
+
+	ch <- Event{
+		Rawcode: Keycode["b"],
+		Kind:    KeyDown,
+	}
+
+	select {
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for keydown")
+	case <-secondDone:
+	}
+}
+
+func TestStartContextCancel(t *testing.T) {
+	h := New(WithSource(NewFakeSource()))
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := h.StartContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	timeout := time.After(TIMEOUT)
+	for {
+		select {
+		case <-timeout:
+			t.Fatal("Timeout waiting for the event channel to close")
+		case _, ok := <-ch:
+			if !ok {
+				// closing an already stopped hook is a no-op
+				h.Close()
+				h.Close()
+				return
+			}
+		}
+	}
+}
+
+func TestStartContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := New(WithSource(NewFakeSource())).StartContext(ctx); err != context.Canceled {
+		t.Fatal("Expected context.Canceled, got", err)
+	}
+}
+
+func TestRunReturnsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- New(WithSource(NewFakeSource())).Run(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for Run to return")
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEndIsIdempotent(t *testing.T) {
+	fakeDefault(t)
+	Start()
+	End()
+	End()
+}
+
+func TestStatusError(t *testing.T) {
+	if err := statusError(statusSuccess); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	cases := map[int]error{
+		statusXOpenDisplay:     ErrDisplayUnavailable,
+		statusXRecordNotFound:  ErrXRecordMissing,
+		statusAXAPIDisabled:    ErrAccessibilityDenied,
+		statusSetWindowsHookEx: ErrWindowsHook,
+		0xFF:                   ErrHookFailed,
+	}
+	for status, want := range cases {
+		err := statusError(status)
+		if !errors.Is(err, want) {
+			t.Fatalf("status %#x: expected %v, got %v", status, want, err)
+		}
+
+		var nerr *NativeError
+		if !errors.As(err, &nerr) || nerr.Status != status {
+			t.Fatalf("status %#x: expected a NativeError, got %v", status, err)
+		}
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	h := New()
+	count := make(chan string, 4)
+	b, err := h.Register(KeyDown, []string{"a"}, func(e Event) {
+		count <- "a"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Register(KeyDown, []string{"b"}, func(e Event) {
+		count <- "b"
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	ch <- Event{Rawcode: Keycode["a"], Kind: KeyDown}
+	b.Unregister()
+	// unregistering twice is a no-op
+	b.Unregister()
+	ch <- Event{Rawcode: Keycode["a"], Kind: KeyUp}
+	ch <- Event{Rawcode: Keycode["a"], Kind: KeyDown}
+	ch <- Event{Rawcode: Keycode["b"], Kind: KeyDown}
+	close(ch)
+	<-done
+	close(count)
+
+	got := ""
+	for key := range count {
+		got += key
+	}
+	if got != "ab" {
+		t.Fatal("Expected callbacks ab, got", got)
+	}
+}
+
+func TestReplaceBinding(t *testing.T) {
+	h := New()
+	got := make(chan string, 4)
+	b, err := h.Register(KeyDown, []string{"a"}, func(e Event) {
+		got <- "old"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.ReplaceBinding(b, KeyDown, []string{"ctrl", "b"}, func(e Event) {
+		got <- "new"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := New().ReplaceBinding(b, KeyDown, []string{"c"}, nil); err != ErrForeignBinding {
+		t.Fatal("Expected ErrForeignBinding, got", err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+	ch <- Event{Rawcode: Keycode["a"], Kind: KeyDown}
+	ch <- Event{Rawcode: Keycode["ctrl"], Kind: KeyDown}
+	ch <- Event{Rawcode: Keycode["b"], Kind: KeyDown}
+	close(ch)
+	<-done
+	close(got)
+
+	calls := []string{}
+	for call := range got {
+		calls = append(calls, call)
+	}
+	if len(calls) != 1 || calls[0] != "new" {
+		t.Fatal("Expected only the replaced callback, got", calls)
+	}
+}
+
+// TestConcurrentRegister is meant to be run with go test -race
+func TestConcurrentRegister(t *testing.T) {
+	h := New()
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				b, err := h.Register(KeyDown, []string{"ctrl", key}, func(e Event) {})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if err := h.ReplaceBinding(b, KeyUp, []string{key}, nil); err != nil {
+					t.Error(err)
+					return
+				}
+				b.Unregister()
+			}
+		}(key)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			h.Close()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"ctrl", "a", "b", "c", "d"} {
+			ch <- Event{Rawcode: Keycode[key], Kind: KeyDown}
+			ch <- Event{Rawcode: Keycode[key], Kind: KeyUp}
+		}
+	}
+
+	wg.Wait()
+	close(ch)
+	<-done
+}
+
+func TestRegisterUnknownKey(t *testing.T) {
+	h := New()
+
+	_, err := h.Register(KeyDown, []string{"ctrl", "shitf"}, func(e Event) {})
+	var kerr *UnknownKeyError
+	if !errors.As(err, &kerr) {
+		t.Fatal("Expected an UnknownKeyError, got", err)
+	}
+	if kerr.Name != "shitf" || kerr.Button {
+		t.Fatal("Unexpected error", kerr)
+	}
+	if len(kerr.Suggestions) == 0 || kerr.Suggestions[0] != "shift" {
+		t.Fatal("Expected shift to be suggested, got", kerr.Suggestions)
+	}
+
+	_, err = h.Register(MouseDown, []string{"mlef"}, func(e Event) {})
+	if !errors.As(err, &kerr) || !kerr.Button {
+		t.Fatal("Expected an UnknownKeyError for a button, got", err)
+	}
+	if len(kerr.Suggestions) == 0 || kerr.Suggestions[0] != "mleft" {
+		t.Fatal("Expected mleft to be suggested, got", kerr.Suggestions)
+	}
+
+	b, err := h.Register(KeyDown, []string{"a"}, func(e Event) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.ReplaceBinding(b, KeyDown, []string{"nope"}, nil); !errors.As(err, &kerr) {
+		t.Fatal("Expected an UnknownKeyError, got", err)
+	}
+}
+
+func BenchmarkHandleManyCombinations(b *testing.B) {
+	h := New()
+	names := keyNames()
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			if _, err := h.Register(KeyDown, []string{names[i], names[j]}, func(e Event) {}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	down := Event{Rawcode: Keycode["a"], Kind: KeyDown}
+	up := Event{Rawcode: Keycode["a"], Kind: KeyUp}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.handle(down)
+		h.handle(up)
+	}
+}
+
+func TestMouseWithModifier(t *testing.T) {
+	h := New()
+	calls := make(chan bool, 4)
+	if _, err := h.RegisterHotkey(MouseDown, "ctrl+mleft", func(e Event) {
+		calls <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	// without ctrl
+	ch <- Event{Button: MouseMap["left"], Kind: MouseDown}
+	ch <- Event{Button: MouseMap["left"], Kind: MouseUp}
+	// with ctrl
+	ch <- Event{Rawcode: Keycode["ctrl"], Kind: KeyDown}
+	ch <- Event{Button: MouseMap["left"], Kind: MouseDown}
+	ch <- Event{Button: MouseMap["left"], Kind: MouseUp}
+	// wrong button
+	ch <- Event{Button: MouseMap["right"], Kind: MouseDown}
+	close(ch)
+	<-done
+
+	if len(calls) != 1 {
+		t.Fatal("Expected 1 ctrl+click, got", len(calls))
+	}
+}
+
+func TestMouseChord(t *testing.T) {
+	h := New()
+	down := make(chan bool, 4)
+	up := make(chan bool, 4)
+	if _, err := h.Register(MouseDown, []string{"mleft", "mright"}, func(e Event) {
+		down <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Register(MouseUp, []string{"mright", "mleft"}, func(e Event) {
+		up <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	ch <- Event{Button: MouseMap["left"], Kind: MouseDown}
+	ch <- Event{Button: MouseMap["right"], Kind: MouseDown}
+	ch <- Event{Button: MouseMap["left"], Kind: MouseUp}
+	ch <- Event{Button: MouseMap["right"], Kind: MouseUp}
+	close(ch)
+	<-done
+
+	if len(down) != 1 || len(up) != 1 {
+		t.Fatal("Expected 1 chord down and up, got", len(down), len(up))
+	}
+}
+
+func TestMouseRegisterErrors(t *testing.T) {
+	h := New()
+	if _, err := h.Register(MouseDown, []string{"ctrl"}, func(e Event) {}); err == nil {
+		t.Fatal("Expected an error for a mouse binding without buttons")
+	}
+
+	_, err := h.Register(MouseDown, []string{"ctlr", "mleft"}, func(e Event) {})
+	var uerr *UnknownKeyError
+	if !errors.As(err, &uerr) || uerr.Name != "ctlr" {
+		t.Fatal("Expected an UnknownKeyError for ctlr, got", err)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+Hotkey grammar
+
+	hotkey = name { separator name }
+	separator = "+" | "-"
+	name = key | alias | button | "-"
+
+Names are case insensitive and may be surrounded by spaces. A key is any
+name of WindowsVKCodes, with or without its underscores ("page_up" or
+"pageup"). A button is one of mleft, mright or mcenter, or for MouseWheel
+bindings wheelUp, wheelDown, wheelLeft or wheelRight. The aliases are
+listed in hotkeyAliases and guiAliases, for example cmd, win, super and
+meta for the gui key or esc for escape. A "-" where a name is expected is the minus
+key itself, so "ctrl+-" and "ctrl--" both press ctrl and minus. The generic ctrl, shift and alt keys match
+either side, lctrl or right_control only their own one.
+
+	hook.ParseHotkey("Ctrl+Shift+Q")
+	hook.ParseHotkey("ctrl-alt-delete")
+	hook.ParseHotkey("shift + mleft")
+	hook.ParseHotkey("ctrl+-")
+*/
+
+// Errors wrapped by a ParseError
+var (
+	ErrEmptyHotkey  = errors.New("empty hotkey")
+	ErrEmptyName    = errors.New("missing key name")
+	ErrUnknownName  = errors.New("unknown key or button")
+	ErrDuplicateKey = errors.New("duplicate key")
+)
+
+// hotkeyAliases maps the alternative names of the hotkey grammar
+// to the names of WindowsVKCodes and the mouse buttons
+var hotkeyAliases = map[string]string{
+	"control": "ctrl",
+	"option":  "alt",
+	"opt":     "alt",
+
+	"lctrl":  "left_control",
+	"rctrl":  "right_control",
+	"lshift": "left_shift",
+	"rshift": "right_shift",
+	"lalt":   "left_alt",
+	"ralt":   "right_alt",
+	"lcmd":   "left_gui",
+	"rcmd":   "right_gui",
+	"lwin":   "left_gui",
+	"rwin":   "right_gui",
+	"lsuper": "left_gui",
+	"rsuper": "right_gui",
+	"lmeta":  "left_gui",
+	"rmeta":  "right_gui",
+
+	"return":    "enter",
+	"del":       "delete",
+	"ins":       "insert",
+	"pgup":      "page_up",
+	"pgdn":      "page_down",
+	"caps":      "caps_lock",
+	"spacebar":  "space",
+	"prtsc":     "print_screen",
+	"plus":      "equals",
+	"backquote": "grave",
+
+	",":  "comma",
+	".":  "period",
+	"/":  "slash",
+	"\\": "backslash",
+	";":  "semicolon",
+	"'":  "quote",
+	"`":  "grave",
+	"=":  "equals",
+	"-":  "minus",
+	"[":  "open_bracket",
+	"]":  "close_bracket",
+
+	"mouseleft":   "mleft",
+	"mouseright":  "mright",
+	"mousecenter": "mcenter",
+	"mmiddle":     "mcenter",
+	"lmb":         "mleft",
+	"rmb":         "mright",
+	"mmb":         "mcenter",
+	"wheelup":     "wheelUp",
+	"wheeldown":   "wheelDown",
+	"wheelleft":   "wheelLeft",
+	"wheelright":  "wheelRight",
+}
+
+// hotkeyButtons are the mouse button names of the hotkey grammar
+var hotkeyButtons = map[string]bool{
+	"mleft":      true,
+	"mright":     true,
+	"mcenter":    true,
+	"wheelUp":    true,
+	"wheelDown":  true,
+	"wheelLeft":  true,
+	"wheelRight": true,
+}
+
+// Hotkey is a parsed hotkey string
+type Hotkey struct {
+	// Keys holds the names of WindowsVKCodes and gui, the generic key
+	// of left_gui and right_gui, in the order they were written
+	Keys []string
+	// Buttons holds the mouse buttons, mleft, mright or mcenter,
+	// and the wheel directions like wheelUp
+	Buttons []string
+}
+
+// String returns the hotkey in the canonical "ctrl+shift+q" form
+func (hk Hotkey) String() string {
+	return strings.Join(append(append([]string{}, hk.Keys...), hk.Buttons...), "+")
+}
+
+// ParseError describes a hotkey that could not be parsed
+type ParseError struct {
+	Input string
+	// Token is the offending name and Offset its byte offset in Input
+	Token  string
+	Offset int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("hook: parsing hotkey %q: %v at offset %d", e.Input, e.Err, e.Offset)
+	}
+
+	return fmt.Sprintf("hook: parsing hotkey %q: %v %q at offset %d", e.Input, e.Err, e.Token, e.Offset)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseHotkey parses a hotkey like "ctrl+shift+q",
+// see the grammar at the top of hotkey.go
+func ParseHotkey(s string) (Hotkey, error) {
+	hk := Hotkey{}
+	if strings.TrimSpace(s) == "" {
+		return hk, &ParseError{Input: s, Err: ErrEmptyHotkey}
+	}
+
+	seen := make(map[string]bool)
+	for start, end := 0, 0; end < len(s); start = end + 1 {
+		// a name ends at the next separator, unless it starts
+		// with a "-", which is the minus key then
+		end = start + len(s[start:]) - len(strings.TrimLeft(s[start:], " \t"))
+		if end < len(s) && s[end] == '-' {
+			end++
+		}
+		for end < len(s) && s[end] != '+' && s[end] != '-' {
+			end++
+		}
+
+		raw := s[start:end]
+		token := strings.TrimSpace(raw)
+		offset := start + strings.Index(raw, token)
+
+		if token == "" {
+			return hk, &ParseError{Input: s, Offset: offset, Err: ErrEmptyName}
+		}
+
+		name, ok := hotkeyName(token)
+		if !ok {
+			return hk, &ParseError{Input: s, Token: token, Offset: offset, Err: ErrUnknownName}
+		}
+		if seen[name] {
+			return hk, &ParseError{Input: s, Token: token, Offset: offset, Err: ErrDuplicateKey}
+		}
+		seen[name] = true
+
+		if hotkeyButtons[name] {
+			hk.Buttons = append(hk.Buttons, name)
+		} else {
+			hk.Keys = append(hk.Keys, name)
+		}
+	}
+
+	return hk, nil
+}
+
+// hotkeyName resolves a token of the hotkey grammar to a key
+// or button name
+func hotkeyName(token string) (string, bool) {
+	name := strings.ToLower(token)
+	if alias, ok := hotkeyAliases[name]; ok {
+		name = alias
+	}
+	if guiAliases[name] {
+		return "gui", true
+	}
+
+	if hotkeyButtons[name] {
+		return name, true
+	}
+	if _, ok := WindowsVKCodes[name]; ok {
+		return name, true
+	}
+
+	key, ok := compactKeyNames[name]
+	return key, ok
+}
+
+// RegisterHotkey registers a hotkey string on the default Hook
+func RegisterHotkey(when Kind, hotkey string, cb func(Event), opts ...BindOption) (*Binding, error) {
+	return defaultHook.RegisterHotkey(when, hotkey, cb, opts...)
+}
+
+// RegisterHotkey parses hotkey with ParseHotkey and registers it
+//
+// Keyboard kinds take keys only, mouse kinds one or more buttons
+// and the keys that have to be held, like "ctrl+mleft".
+func (h *Hook) RegisterHotkey(when Kind, hotkey string, cb func(Event), opts ...BindOption) (*Binding, error) {
+	hk, err := ParseHotkey(hotkey)
+	if err != nil {
+		return nil, err
+	}
+
+	if when == KeyDown || when == KeyUp {
+		if len(hk.Buttons) != 0 {
+			return nil, fmt.Errorf("hook: hotkey %q has mouse buttons, they can not be bound to key events", hotkey)
+		}
+		return h.Register(when, hk.Keys, cb, opts...)
+	}
+
+	if len(hk.Buttons) == 0 {
+		return nil, fmt.Errorf("hook: hotkey %q has no mouse button to be bound to mouse events", hotkey)
+	}
+	return h.Register(when, append(hk.Buttons, hk.Keys...), cb, opts...)
+}
@@ -0,0 +1,117 @@
+package hook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseHotkey(t *testing.T) {
+	cases := map[string]string{
+		"Ctrl+Shift+Q":      "ctrl+shift+q",
+		"ctrl-alt-delete":   "ctrl+alt+delete",
+		" shift + mleft ":   "shift+mleft",
+		"CMD+space":         "gui+space",
+		"super-PgUp":        "gui+page_up",
+		"rctrl+pageup":      "right_control+page_up",
+		"control+,":         "ctrl+comma",
+		"alt+RMB":           "alt+mright",
+		"print_screen":      "print_screen",
+		"Escape":            "escape",
+		"kp_enter+numlock":  "kp_enter+num_lock",
+		"win+shift+mcenter": "gui+shift+mcenter",
+		"ctrl+-":            "ctrl+minus",
+		"ctrl--":            "ctrl+minus",
+		"-":                 "minus",
+		"- + shift":         "minus+shift",
+		"alt+-+a":           "alt+minus+a",
+	}
+
+	for input, want := range cases {
+		hk, err := ParseHotkey(input)
+		if err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if hk.String() != want {
+			t.Fatalf("%q: expected %q, got %q", input, want, hk.String())
+		}
+	}
+}
+
+func TestParseHotkeyErrors(t *testing.T) {
+	cases := []struct {
+		input  string
+		err    error
+		token  string
+		offset int
+	}{
+		{"", ErrEmptyHotkey, "", 0},
+		{"ctrl+shift+foo", ErrUnknownName, "foo", 11},
+		{"ctrl++q", ErrEmptyName, "", 5},
+		{"ctrl+", ErrEmptyName, "", 5},
+		{"ctrl+a+control", ErrDuplicateKey, "control", 7},
+		{"ctrl-", ErrEmptyName, "", 5},
+		{"ctrl+-a", ErrUnknownName, "-a", 5},
+		{"ctrl+minus+-", ErrDuplicateKey, "-", 11},
+	}
+
+	for _, c := range cases {
+		_, err := ParseHotkey(c.input)
+		if !errors.Is(err, c.err) {
+			t.Fatalf("%q: expected %v, got %v", c.input, c.err, err)
+		}
+
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf("%q: expected a ParseError, got %v", c.input, err)
+		}
+		if perr.Token != c.token || perr.Offset != c.offset {
+			t.Fatalf("%q: expected %q at %d, got %q at %d",
+				c.input, c.token, c.offset, perr.Token, perr.Offset)
+		}
+	}
+}
+
+func TestKeyNamesStable(t *testing.T) {
+	names := keyNames()
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Fatalf("Expected sorted key names, got %q before %q", names[i-1], names[i])
+		}
+	}
+
+	// ctrl and esc have a second name
+	if name := WindowsVKCodeToName[0x11]; name != "control" {
+		t.Fatal("Expected the first sorted name of VK_CONTROL, got", name)
+	}
+	if name := WindowsVKCodeToName[0x1B]; name != "esc" {
+		t.Fatal("Expected the first sorted name of VK_ESCAPE, got", name)
+	}
+}
+
+func TestRegisterHotkey(t *testing.T) {
+	h := New()
+	done := make(chan bool, 1)
+	if _, err := h.RegisterHotkey(KeyDown, "Ctrl+A", func(e Event) {
+		done <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.RegisterHotkey(KeyDown, "ctrl+mleft", func(e Event) {}); err == nil {
+		t.Fatal("Expected an error for a mouse button in a key binding")
+	}
+
+	ch := make(chan Event)
+	defer close(ch)
+	h.Process(ch)
+
+	ch <- Event{Rawcode: Keycode["ctrl"], Kind: KeyDown}
+	ch <- Event{Rawcode: Keycode["a"], Kind: KeyDown}
+
+	select {
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for keydown")
+	case <-done:
+	}
+}
@@ -10,7 +10,12 @@
 
 package hook
 
-import "github.com/vcaesar/keycode"
+import (
+	"sort"
+	"strings"
+
+	"github.com/vcaesar/keycode"
+)
 
 // MouseMap defines the robotgo hook mouse's code map
 var MouseMap = keycode.MouseMap
@@ -22,3 +27,63 @@ var Keycode = WindowsVKCodes
 
 // Special defines the special key map
 var Special = keycode.Special
+
+// keyCode returns the code of a key name Register accepts,
+// a name of WindowsVKCodes or the generic gui key
+func keyCode(name string) (Code, bool) {
+	if name == "gui" {
+		return guiKey, true
+	}
+
+	code, ok := WindowsVKCodes[name]
+	return Code(code), ok
+}
+
+// keyNames returns the key names Register accepts, sorted
+func keyNames() []string {
+	names := make([]string, 0, len(WindowsVKCodes)+1)
+	for name := range WindowsVKCodes {
+		names = append(names, name)
+	}
+	names = append(names, "gui")
+	sort.Strings(names)
+	return names
+}
+
+// compactKeyNames maps the key names without their underscores to
+// the names, like "pageup" to "page_up", the first sorted one wins
+var compactKeyNames = func() map[string]string {
+	m := make(map[string]string)
+	for _, name := range keyNames() {
+		compact := strings.ReplaceAll(name, "_", "")
+		if _, ok := m[compact]; !ok {
+			m[compact] = name
+		}
+	}
+	return m
+}()
+
+// mouseButton returns the MouseMap code of a button name,
+// mleft, mright and mcenter included
+func mouseButton(name string) (uint16, bool) {
+	switch name {
+	case "mleft":
+		name = "left"
+	case "mright":
+		name = "right"
+	case "mcenter":
+		name = "center"
+	}
+
+	code, ok := MouseMap[name]
+	return code, ok
+}
+
+// buttonNames returns the mouse button names Register accepts
+func buttonNames() []string {
+	names := []string{"mleft", "mright", "mcenter"}
+	for name := range MouseMap {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,255 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import "strings"
+
+// Modifiers is the modifier bitfield sent in Event.Mask,
+// the bits match the MASK_ values of hook/iohook.h
+type Modifiers uint16
+
+const (
+	ModShiftLeft Modifiers = 1 << iota
+	ModCtrlLeft
+	ModMetaLeft
+	ModAltLeft
+	ModShiftRight
+	ModCtrlRight
+	ModMetaRight
+	ModAltRight
+	ModButton1
+	ModButton2
+	ModButton3
+	ModButton4
+	ModButton5
+	ModNumLock
+	ModCapsLock
+	ModScrollLock
+
+	ModShift = ModShiftLeft | ModShiftRight
+	ModCtrl  = ModCtrlLeft | ModCtrlRight
+	ModMeta  = ModMetaLeft | ModMetaRight
+	ModAlt   = ModAltLeft | ModAltRight
+
+	// ModKeys holds the bits of the modifier keys
+	ModKeys = ModShift | ModCtrl | ModMeta | ModAlt
+	// ModLocks holds the bits of the lock keys
+	ModLocks = ModNumLock | ModCapsLock | ModScrollLock
+	// ModButtons holds the bits of the mouse buttons
+	ModButtons = ModButton1 | ModButton2 | ModButton3 | ModButton4 | ModButton5
+)
+
+// Modifiers returns the modifier state of the event
+func (e Event) Modifiers() Modifiers {
+	return Modifiers(e.Mask)
+}
+
+// Shift reports whether either shift key is held
+func (m Modifiers) Shift() bool { return m&ModShift != 0 }
+
+// ShiftLeft reports whether the left shift key is held
+func (m Modifiers) ShiftLeft() bool { return m&ModShiftLeft != 0 }
+
+// ShiftRight reports whether the right shift key is held
+func (m Modifiers) ShiftRight() bool { return m&ModShiftRight != 0 }
+
+// Ctrl reports whether either control key is held
+func (m Modifiers) Ctrl() bool { return m&ModCtrl != 0 }
+
+// CtrlLeft reports whether the left control key is held
+func (m Modifiers) CtrlLeft() bool { return m&ModCtrlLeft != 0 }
+
+// CtrlRight reports whether the right control key is held
+func (m Modifiers) CtrlRight() bool { return m&ModCtrlRight != 0 }
+
+// Alt reports whether either alt key is held
+func (m Modifiers) Alt() bool { return m&ModAlt != 0 }
+
+// AltLeft reports whether the left alt key is held
+func (m Modifiers) AltLeft() bool { return m&ModAltLeft != 0 }
+
+// AltRight reports whether the right alt key is held
+func (m Modifiers) AltRight() bool { return m&ModAltRight != 0 }
+
+// Meta reports whether either meta (gui, cmd, win) key is held
+func (m Modifiers) Meta() bool { return m&ModMeta != 0 }
+
+// MetaLeft reports whether the left meta key is held
+func (m Modifiers) MetaLeft() bool { return m&ModMetaLeft != 0 }
+
+// MetaRight reports whether the right meta key is held
+func (m Modifiers) MetaRight() bool { return m&ModMetaRight != 0 }
+
+// NumLock reports whether num lock is on
+func (m Modifiers) NumLock() bool { return m&ModNumLock != 0 }
+
+// CapsLock reports whether caps lock is on
+func (m Modifiers) CapsLock() bool { return m&ModCapsLock != 0 }
+
+// ScrollLock reports whether scroll lock is on
+func (m Modifiers) ScrollLock() bool { return m&ModScrollLock != 0 }
+
+// Buttons returns the held mouse buttons, numbered
+// from 1 to 5 like Event.Button
+func (m Modifiers) Buttons() []uint16 {
+	buttons := []uint16{}
+	for i := uint16(0); i < 5; i++ {
+		if m&(ModButton1<<i) != 0 {
+			buttons = append(buttons, i+1)
+		}
+	}
+	return buttons
+}
+
+// modifierNames are the names printed by Modifiers.String, in order
+var modifierNames = []struct {
+	mod  Modifiers
+	name string
+}{
+	{ModCtrl, "Ctrl"},
+	{ModAlt, "Alt"},
+	{ModShift, "Shift"},
+	{ModMeta, "Meta"},
+	{ModCapsLock, "CapsLock"},
+	{ModNumLock, "NumLock"},
+	{ModScrollLock, "ScrollLock"},
+	{ModButton1, "Button1"},
+	{ModButton2, "Button2"},
+	{ModButton3, "Button3"},
+	{ModButton4, "Button4"},
+	{ModButton5, "Button5"},
+}
+
+// String returns the held modifiers like "Ctrl+Shift",
+// without telling the left and right keys apart
+func (m Modifiers) String() string {
+	names := []string{}
+	for _, n := range modifierNames {
+		if m&n.mod != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, "+")
+}
+
+// keyModifiers maps the key codes of WindowsVKCodes and guiKey to their bits
+var keyModifiers = map[Code]Modifiers{
+	0x10:   ModShift,
+	0xA0:   ModShiftLeft,
+	0xA1:   ModShiftRight,
+	0x11:   ModCtrl,
+	0xA2:   ModCtrlLeft,
+	0xA3:   ModCtrlRight,
+	0x12:   ModAlt,
+	0xA4:   ModAltLeft,
+	0xA5:   ModAltRight,
+	0x5B:   ModMetaLeft,
+	0x5C:   ModMetaRight,
+	guiKey: ModMeta,
+	0x14:   ModCapsLock,
+	0x90:   ModNumLock,
+	0x91:   ModScrollLock,
+}
+
+// guiKey is the code of the generic gui key. Unlike shift, ctrl and
+// alt it has no VK code, so it is kept out of WindowsVKCodes.
+const guiKey Code = 0x100
+
+// guiAliases are the names of the generic gui key in the hotkey grammar
+var guiAliases = map[string]bool{
+	"gui":     true,
+	"cmd":     true,
+	"command": true,
+	"win":     true,
+	"windows": true,
+	"super":   true,
+	"meta":    true,
+}
+
+// modifierSides maps the generic modifier keys to their left and right keys
+var modifierSides = map[Code][2]Code{
+	0x10:   {0xA0, 0xA1}, // shift
+	0x11:   {0xA2, 0xA3}, // ctrl
+	0x12:   {0xA4, 0xA5}, // alt
+	guiKey: {0x5B, 0x5C}, // gui
+}
+
+// genericKey returns the generic key of a left or right modifier
+func genericKey(code Code) (Code, bool) {
+	for generic, sides := range modifierSides {
+		if code == sides[0] || code == sides[1] {
+			return generic, true
+		}
+	}
+	return 0, false
+}
+
+// isPressed reports whether key is held, a generic
+// modifier key is held when either of its sides is
+func isPressed(pressed map[Code]bool, key Code) bool {
+	if pressed[key] {
+		return true
+	}
+
+	sides, ok := modifierSides[key]
+	return ok && (pressed[sides[0]] || pressed[sides[1]])
+}
+
+// modifiersOf returns the bits of the modifier keys of c
+func modifiersOf(c combination) (m Modifiers) {
+	for _, code := range c {
+		m |= keyModifiers[code]
+	}
+	return
+}
+
+// MatchMode selects how a combination treats modifiers
+// that are held but not part of it
+type MatchMode uint8
+
+const (
+	// MatchSuperset fires while other modifiers are held as well,
+	// so ctrl+a also fires on ctrl+shift+a
+	MatchSuperset MatchMode = iota
+	// MatchExact only fires when no other modifier is held
+	MatchExact
+)
+
+// BindOption configures a binding made with Register
+type BindOption func(*Binding)
+
+// WithMatchMode sets the MatchMode of a binding, MatchSuperset by default
+func WithMatchMode(mode MatchMode) BindOption {
+	return func(b *Binding) {
+		b.match = mode
+	}
+}
+
+// WithIgnoredModifiers makes a binding MatchExact
+// while ignoring the state of the given modifiers,
+// for example ModCapsLock|ModNumLock
+func WithIgnoredModifiers(m Modifiers) BindOption {
+	return func(b *Binding) {
+		b.match = MatchExact
+		b.ignored = m
+	}
+}
+
+// modifiersMatch checks the modifiers of an event against the
+// MatchMode of b, the mouse button bits are never considered
+func (b *Binding) modifiersMatch(mask Modifiers) bool {
+	if b.match != MatchExact {
+		return true
+	}
+
+	extra := mask & (ModKeys | ModLocks) &^ modifiersOf(b.codes) &^ b.ignored
+	return extra == 0
+}
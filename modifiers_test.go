@@ -0,0 +1,204 @@
+package hook
+
+import "testing"
+
+// press sends a key down of every key with the given modifier mask
+func press(ch chan Event, mask Modifiers, keys ...string) {
+	for _, key := range keys {
+		ch <- Event{Rawcode: Keycode[key], Kind: KeyDown, Mask: uint16(mask)}
+	}
+}
+
+// release sends a key up of every key with the given modifier mask
+func release(ch chan Event, mask Modifiers, keys ...string) {
+	for _, key := range keys {
+		ch <- Event{Rawcode: Keycode[key], Kind: KeyUp, Mask: uint16(mask)}
+	}
+}
+
+func TestMatchModes(t *testing.T) {
+	h := New()
+	superset := make(chan bool, 4)
+	exact := make(chan bool, 4)
+	if _, err := h.RegisterHotkey(KeyDown, "ctrl+a", func(e Event) {
+		superset <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.RegisterHotkey(KeyDown, "ctrl+b", func(e Event) {
+		exact <- true
+	}, WithMatchMode(MatchExact)); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	press(ch, ModCtrlLeft, "ctrl", "a")
+	release(ch, 0, "a", "ctrl")
+	press(ch, ModCtrlLeft, "ctrl", "b")
+	release(ch, 0, "b", "ctrl")
+
+	press(ch, ModShiftLeft, "shift")
+	press(ch, ModShiftLeft|ModCtrlLeft, "ctrl", "a", "b")
+	close(ch)
+	<-done
+
+	if len(superset) != 2 {
+		t.Fatal("Expected 2 superset matches, got", len(superset))
+	}
+	if len(exact) != 1 {
+		t.Fatal("Expected 1 exact match, got", len(exact))
+	}
+}
+
+func TestIgnoredModifiers(t *testing.T) {
+	h := New()
+	calls := make(chan bool, 4)
+	if _, err := h.RegisterHotkey(KeyDown, "ctrl+a", func(e Event) {
+		calls <- true
+	}, WithIgnoredModifiers(ModCapsLock|ModNumLock)); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	press(ch, ModCtrlLeft|ModCapsLock|ModNumLock, "ctrl", "a")
+	release(ch, ModCapsLock|ModNumLock, "a", "ctrl")
+	press(ch, ModCtrlLeft|ModAltLeft, "ctrl", "a")
+	close(ch)
+	<-done
+
+	if len(calls) != 1 {
+		t.Fatal("Expected 1 match, got", len(calls))
+	}
+}
+
+func TestModifiers(t *testing.T) {
+	e := Event{Mask: uint16(ModShiftLeft | ModCtrlRight | ModCapsLock | ModButton1 | ModButton3)}
+	m := e.Modifiers()
+
+	if !m.Shift() || !m.ShiftLeft() || m.ShiftRight() {
+		t.Error("Wrong shift state", m)
+	}
+	if !m.Ctrl() || m.CtrlLeft() || !m.CtrlRight() {
+		t.Error("Wrong ctrl state", m)
+	}
+	if m.Alt() || m.Meta() {
+		t.Error("Unexpected alt or meta", m)
+	}
+	if !m.CapsLock() || m.NumLock() || m.ScrollLock() {
+		t.Error("Wrong lock state", m)
+	}
+
+	buttons := m.Buttons()
+	if len(buttons) != 2 || buttons[0] != 1 || buttons[1] != 3 {
+		t.Error("Expected buttons [1 3], got", buttons)
+	}
+
+	if s := m.String(); s != "Ctrl+Shift+CapsLock+Button1+Button3" {
+		t.Error("Unexpected string", s)
+	}
+	if s := (ModShiftRight | ModCtrlLeft).String(); s != "Ctrl+Shift" {
+		t.Error("Expected Ctrl+Shift, got", s)
+	}
+	if s := Modifiers(0).String(); s != "" {
+		t.Error("Expected an empty string, got", s)
+	}
+}
+
+func TestModifierSides(t *testing.T) {
+	h := New()
+	generic := make(chan bool, 4)
+	right := make(chan bool, 4)
+	if _, err := h.RegisterHotkey(KeyDown, "ctrl+k", func(e Event) {
+		generic <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.RegisterHotkey(KeyDown, "rctrl+k", func(e Event) {
+		right <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	press(ch, ModCtrlLeft, "left_control", "k")
+	release(ch, 0, "k", "left_control")
+	press(ch, ModCtrlRight, "right_control", "k")
+	release(ch, 0, "k", "right_control")
+	// k first, the ctrl key completes the combination
+	press(ch, ModCtrlLeft, "k", "left_control")
+	close(ch)
+	<-done
+
+	if len(generic) != 3 {
+		t.Fatal("Expected 3 ctrl+k, got", len(generic))
+	}
+	if len(right) != 1 {
+		t.Fatal("Expected 1 rctrl+k, got", len(right))
+	}
+}
+
+func TestGuiSides(t *testing.T) {
+	h := New()
+	calls := make(chan bool, 4)
+	if _, err := h.RegisterHotkey(KeyDown, "cmd+q", func(e Event) {
+		calls <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	press(ch, ModMetaRight, "right_gui", "q")
+	release(ch, 0, "q", "right_gui")
+	press(ch, ModMetaLeft, "left_gui", "q")
+	close(ch)
+	<-done
+
+	if len(calls) != 2 {
+		t.Fatal("Expected cmd+q with either gui key, got", len(calls))
+	}
+}
+
+func TestGuiNotVKCode(t *testing.T) {
+	if _, ok := WindowsVKCodes["gui"]; ok {
+		t.Fatal("Expected gui to be kept out of WindowsVKCodes")
+	}
+	if _, ok := WindowsVKCodeToName[uint16(guiKey)]; ok {
+		t.Fatal("Expected no VK name for the gui key")
+	}
+
+	h := New()
+	if _, err := h.Register(KeyDown, []string{"gui", "q"}, func(e Event) {}); err != nil {
+		t.Fatal("Expected Register to take gui, got", err)
+	}
+}
+
+func TestModifierSidesExact(t *testing.T) {
+	h := New()
+	calls := make(chan bool, 4)
+	if _, err := h.RegisterHotkey(KeyDown, "lshift+a", func(e Event) {
+		calls <- true
+	}, WithMatchMode(MatchExact)); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	press(ch, ModShiftLeft, "left_shift", "a")
+	release(ch, 0, "a", "left_shift")
+	press(ch, ModShiftLeft|ModShiftRight, "left_shift", "right_shift", "a")
+	close(ch)
+	<-done
+
+	if len(calls) != 1 {
+		t.Fatal("Expected 1 match, got", len(calls))
+	}
+}
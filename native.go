@@ -0,0 +1,150 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+//go:build !nocgo
+
+package hook
+
+/*
+#cgo darwin CFLAGS: -x objective-c -Wno-deprecated-declarations
+#cgo darwin LDFLAGS: -framework Cocoa
+
+#cgo linux CFLAGS:-I/usr/src -std=gnu99
+#cgo linux LDFLAGS: -L/usr/src -lX11 -lXtst
+#cgo linux LDFLAGS: -lX11-xcb -lxcb -lxcb-xkb -lxkbcommon -lxkbcommon-x11
+//#cgo windows LDFLAGS: -lgdi32 -luser32
+
+#include "event/goEvent.h"
+*/
+import "C"
+
+import (
+	"context"
+	"sync"
+	"unsafe"
+)
+
+// startNative subscribes src to the native hook, starting it if needed
+//
+// It returns once the hook is enabled, or with the error
+// that kept it from starting.
+func startNative(ctx context.Context, src *nativeSource) error {
+	native.lifecycle.Lock()
+	defer native.lifecycle.Unlock()
+
+	if !subscribe(src) {
+		return nil
+	}
+
+	native.Lock()
+	enabled := make(chan struct{})
+	native.enabled = enabled
+	native.enabledOnce = &sync.Once{}
+	native.Unlock()
+
+	hookLog("%s\n", "starting C.start_ev")
+	status := make(chan int, 1)
+	running := make(chan struct{})
+	native.running = running
+	go func() {
+		defer close(running)
+		status <- int(C.start_ev())
+	}()
+
+	native.stop = make(chan struct{})
+	native.delivering.Add(1)
+	go func(stop chan struct{}) {
+		defer native.delivering.Done()
+		deliver(stop, wake, takeNative, dispatch)
+	}(native.stop)
+
+	err := awaitEnabled(ctx, enabled, status, func() { C.stop_event() })
+	if err == nil {
+		return nil
+	}
+
+	native.Lock()
+	delete(native.subscribers, src)
+	native.Unlock()
+
+	close(native.stop)
+	native.delivering.Wait()
+	<-running
+	C.endPoll()
+	drain(make([]rawEvent, deliverBatch), takeNative, dispatch)
+
+	return err
+}
+
+// rawEvent has to have the layout of C.go_event
+var _ = [1]struct{}{}[unsafe.Sizeof(rawEvent{})-uintptr(C.sizeof_go_event)]
+
+// wake is signaled by go_notify whenever the C hook buffered an event
+var wake = make(chan struct{}, 1)
+
+// takeNative copies the events buffered by the C hook to buf
+func takeNative(buf []rawEvent) int {
+	return int(C.take_events((*C.go_event)(unsafe.Pointer(&buf[0])), C.int(len(buf))))
+}
+
+// nativeDropped returns the number of events the C hook dropped
+// because the ring of event/bridge.h was full
+func nativeDropped() uint64 {
+	return uint64(C.dropped_events())
+}
+
+// stopNative unsubscribes src, stopping the native hook
+// once nobody is listening anymore
+func stopNative(src *nativeSource) {
+	native.lifecycle.Lock()
+	defer native.lifecycle.Unlock()
+
+	native.Lock()
+	ok := native.subscribers[src]
+	delete(native.subscribers, src)
+	last := ok && len(native.subscribers) == 0
+	native.Unlock()
+
+	if !last {
+		return
+	}
+
+	close(native.stop)
+	native.delivering.Wait()
+
+	// the next start has to wait until hook_run tore the hook down
+	C.stop_event()
+	<-native.running
+	C.endPoll()
+	drain(make([]rawEvent, deliverBatch), takeNative, dispatch)
+}
+
+// postNative hands e to hook_post_event of libuiohook
+func postNative(e Event) error {
+	status := C.post_event(C.uint8_t(e.Kind), C.uint16_t(e.Keycode), C.uint16_t(e.Button),
+		C.int16_t(e.X), C.int16_t(e.Y), C.int32_t(e.Rotation), C.uint8_t(e.Direction))
+	return statusError(int(status))
+}
+
+// AddEvent add the block event listener
+func addEvent(key string) int {
+	cs := C.CString(key)
+	defer C.free(unsafe.Pointer(cs))
+
+	eve := C.add_event(cs)
+	geve := int(eve)
+
+	return geve
+}
+
+// StopEvent stop the block event listener
+func StopEvent() {
+	C.stop_event()
+}
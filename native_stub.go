@@ -0,0 +1,45 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+//go:build !cgo || nocgo
+
+package hook
+
+import (
+	"context"
+	"fmt"
+)
+
+// Without cgo there is no native hook, the bindings still work
+// with the events of a Source like FakeSource or a channel given
+// to Process.
+
+// startNative always fails with ErrBackendUnavailable
+func startNative(ctx context.Context, src *nativeSource) error {
+	return fmt.Errorf("%w, built without cgo", ErrBackendUnavailable)
+}
+
+func stopNative(src *nativeSource) {}
+
+func nativeDropped() uint64 {
+	return 0
+}
+
+// postNative always fails with ErrBackendUnavailable
+func postNative(e Event) error {
+	return fmt.Errorf("%w, built without cgo", ErrBackendUnavailable)
+}
+
+func addEvent(key string) int {
+	return statusFailure
+}
+
+// StopEvent stop the block event listener
+func StopEvent() {}
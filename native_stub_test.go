@@ -0,0 +1,43 @@
+//go:build !cgo || nocgo
+
+package hook
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStartWithoutBackend(t *testing.T) {
+	h := New()
+	if _, err := h.StartContext(context.Background()); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatal("Expected ErrBackendUnavailable, got", err)
+	}
+	if err := h.Run(context.Background()); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatal("Expected ErrBackendUnavailable from Run, got", err)
+	}
+
+	if _, ok := <-h.Start(); ok {
+		t.Fatal("Expected a closed channel from Start")
+	}
+	if AddEvent("a") {
+		t.Fatal("Expected AddEvent to fail")
+	}
+	if AddMousePos(0, 0) {
+		t.Fatal("Expected AddMousePos to fail")
+	}
+
+	// the hook was never started, so it can take a source now
+	if err := h.SetSource(NewFakeSource()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPostWithoutBackend(t *testing.T) {
+	if err := PostKey("a", true); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatal("Expected ErrBackendUnavailable, got", err)
+	}
+	if err := PostHotkey("ctrl+c"); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatal("Expected ErrBackendUnavailable from PostHotkey, got", err)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBufferSize is the number of events a started Hook buffers
+// for its reader unless WithBufferSize says otherwise
+const DefaultBufferSize = 1024
+
+// OverflowPolicy decides what happens to a new event
+// when the buffer of a Hook is full
+type OverflowPolicy uint8
+
+const (
+	// OverflowBlock waits for the reader to make room. The native
+	// hook is shared and never waits for a single Hook, up to the
+	// buffer size of its events queue up once more meanwhile and
+	// the ones after that are dropped
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the new event
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest buffered event
+	OverflowDropOldest
+	// OverflowCoalesceMoves replaces the newest buffered mouse move
+	// or drag with a new one of the same kind, other events wait
+	// like OverflowBlock
+	OverflowCoalesceMoves
+)
+
+// WithBufferSize sets the number of events buffered for the reader
+// of the channel returned by Start
+func WithBufferSize(n int) Option {
+	return func(h *Hook) {
+		h.bufferSize = max(1, n)
+	}
+}
+
+// WithOverflowPolicy sets what happens to events that do not fit
+// the buffer, OverflowBlock by default
+func WithOverflowPolicy(p OverflowPolicy) Option {
+	return func(h *Hook) {
+		h.overflow = p
+	}
+}
+
+// Stats counts the events of a Hook since it was created
+type Stats struct {
+	// Delivered events were sent on the channel returned by Start
+	Delivered uint64
+	// Dropped events did not fit the buffer
+	Dropped uint64
+	// Coalesced mouse moves were replaced by a later one
+	Coalesced uint64
+	// NativeDropped events did not fit the buffer of the native hook,
+	// it is shared by all Hooks of the process
+	NativeDropped uint64
+}
+
+// stats holds the counters of a Hook
+type stats struct {
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+// GetStats returns the event counters of the default Hook
+func GetStats() Stats {
+	return defaultHook.Stats()
+}
+
+// Stats returns the event counters of h
+func (h *Hook) Stats() Stats {
+	return Stats{
+		Delivered:     h.stats.delivered.Load(),
+		Dropped:       h.stats.dropped.Load(),
+		Coalesced:     h.stats.coalesced.Load(),
+		NativeDropped: nativeDropped(),
+	}
+}
+
+// newSession returns a running session buffering size events
+func newSession(size int, policy OverflowPolicy, st *stats) *session {
+	return runSession(&session{size: size, policy: policy, stats: st})
+}
+
+// newRelay returns a running session that drops the new event instead
+// of waiting for room, for a sender shared by several readers
+//
+// It feeds the session of a Hook, which counts the delivered events.
+func newRelay(size int, policy OverflowPolicy, st *stats) *session {
+	return runSession(&session{size: size, policy: policy, stats: st, relay: true})
+}
+
+// runSession starts pumping the events of s
+func runSession(s *session) *session {
+	s.ev = make(chan Event)
+	s.done = make(chan struct{})
+	s.cond = sync.NewCond(&s.mu)
+
+	s.pumping.Add(1)
+	go s.pump()
+	return s
+}
+
+// send buffers e following the overflow policy
+// unless the session is being closed
+func (s *session) send(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.queue) >= s.size && !s.closed {
+		switch s.policy {
+		case OverflowDropNewest:
+			s.stats.dropped.Add(1)
+			return
+		case OverflowDropOldest:
+			s.queue[0] = Event{}
+			s.queue = s.queue[1:]
+			s.stats.dropped.Add(1)
+			continue
+		case OverflowCoalesceMoves:
+			last := &s.queue[len(s.queue)-1]
+			if isMoveEvent(e) && last.Kind == e.Kind {
+				*last = e
+				s.stats.coalesced.Add(1)
+				return
+			}
+		}
+
+		if s.relay {
+			s.stats.dropped.Add(1)
+			return
+		}
+		s.cond.Wait()
+	}
+
+	if s.closed {
+		return
+	}
+
+	s.queue = append(s.queue, e)
+	s.cond.Broadcast()
+}
+
+// pump moves the buffered events to ev until the session is closed
+func (s *session) pump() {
+	defer s.pumping.Done()
+
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+
+		e := s.queue[0]
+		s.queue[0] = Event{}
+		s.queue = s.queue[1:]
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		select {
+		case s.ev <- e:
+			if !s.relay {
+				s.stats.delivered.Add(1)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// close wakes up the blocked senders and waits for pump to return,
+// done has to be closed first
+func (s *session) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.queue = nil
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	s.pumping.Wait()
+}
+
+func isMoveEvent(ev Event) bool {
+	return ev.Kind == MouseMove || ev.Kind == MouseDrag
+}
@@ -0,0 +1,224 @@
+package hook
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fill sends events to a session nobody reads from yet
+func fill(s *session, events ...Event) {
+	for _, e := range events {
+		s.send(e)
+	}
+}
+
+// waitQueued waits until s buffers n events
+func waitQueued(s *session, n int) {
+	for {
+		s.mu.Lock()
+		queued := len(s.queue)
+		s.mu.Unlock()
+
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// collect reads n events of s
+func collect(t *testing.T, s *session, n int) []Event {
+	out := []Event{}
+	for range n {
+		out = append(out, <-s.ev)
+	}
+
+	s.mu.Lock()
+	left := len(s.queue)
+	s.mu.Unlock()
+	if left != 0 {
+		t.Fatal("Expected no buffered events, got", left)
+	}
+	return out
+}
+
+func key(code uint16) Event {
+	return Event{Kind: KeyDown, Rawcode: code}
+}
+
+func move(x int16) Event {
+	return Event{Kind: MouseMove, X: x}
+}
+
+// closeSession ends a session made by newSession
+func closeSession(s *session) {
+	close(s.done)
+	s.close()
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	st := &stats{}
+	s := newSession(2, OverflowDropNewest, st)
+
+	// the first event is held by pump, two more fill the queue
+	s.send(key(1))
+	waitQueued(s, 0)
+	fill(s, key(2), key(3), key(4))
+
+	got := collect(t, s, 3)
+	if got[0].Rawcode != 1 || got[1].Rawcode != 2 || got[2].Rawcode != 3 {
+		t.Fatal("Unexpected events", got)
+	}
+
+	// pump counts a delivery after the send returned
+	closeSession(s)
+	if st.dropped.Load() != 1 || st.delivered.Load() != 3 {
+		t.Fatal("Expected 1 dropped and 3 delivered, got", st.dropped.Load(), st.delivered.Load())
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	st := &stats{}
+	s := newSession(2, OverflowDropOldest, st)
+	defer closeSession(s)
+
+	s.send(key(1))
+	waitQueued(s, 0)
+	fill(s, key(2), key(3), key(4))
+
+	got := collect(t, s, 3)
+	if got[0].Rawcode != 1 || got[1].Rawcode != 3 || got[2].Rawcode != 4 {
+		t.Fatal("Unexpected events", got)
+	}
+	if st.dropped.Load() != 1 {
+		t.Fatal("Expected 1 dropped, got", st.dropped.Load())
+	}
+}
+
+func TestOverflowCoalesceMoves(t *testing.T) {
+	st := &stats{}
+	s := newSession(2, OverflowCoalesceMoves, st)
+	defer closeSession(s)
+
+	s.send(key(1))
+	waitQueued(s, 0)
+	fill(s, move(1), move(2), move(3), move(4))
+
+	got := collect(t, s, 3)
+	if got[1].X != 1 || got[2].X != 4 {
+		t.Fatal("Unexpected events", got)
+	}
+	if st.coalesced.Load() != 2 || st.dropped.Load() != 0 {
+		t.Fatal("Expected 2 coalesced, got", st.coalesced.Load(), st.dropped.Load())
+	}
+}
+
+func TestOverflowBlockUnblocksOnClose(t *testing.T) {
+	s := newSession(1, OverflowBlock, &stats{})
+
+	s.send(key(1))
+	waitQueued(s, 0)
+	s.send(key(2))
+
+	sent := make(chan bool)
+	go func() {
+		s.send(key(3))
+		sent <- true
+	}()
+
+	closeSession(s)
+	<-sent
+}
+
+// subscribedSource is a native source subscribed to dispatch
+// without starting the C hook
+type subscribedSource struct {
+	*nativeSource
+}
+
+func (s subscribedSource) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.open()
+	native.Lock()
+	native.subscribers[s.nativeSource] = true
+	native.Unlock()
+	return nil
+}
+
+func (s subscribedSource) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	native.Lock()
+	delete(native.subscribers, s.nativeSource)
+	native.Unlock()
+	s.shut()
+}
+
+func TestStalledHook(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowBlock, OverflowDropNewest, OverflowDropOldest} {
+		stalled := New(WithSource(subscribedSource{&nativeSource{}}), WithBufferSize(1), WithOverflowPolicy(policy))
+		if _, err := stalled.StartContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		reader := New(WithSource(subscribedSource{&nativeSource{}}))
+		ch, err := reader.StartContext(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const n = 100
+		dispatched := make(chan bool)
+		go func() {
+			for i := range n {
+				dispatch(key(uint16(i)))
+			}
+			dispatched <- true
+		}()
+
+		for i := range n {
+			select {
+			case e := <-ch:
+				if e.Rawcode != uint16(i) {
+					t.Fatalf("Policy %d: expected event %d, got %v", policy, i, e)
+				}
+			case <-time.After(TIMEOUT):
+				t.Fatalf("Policy %d: timeout waiting for event %d", policy, i)
+			}
+		}
+		select {
+		case <-dispatched:
+		case <-time.After(TIMEOUT):
+			t.Fatalf("Policy %d: expected dispatch not to wait for the stalled Hook", policy)
+		}
+
+		// the stalled Hook does not keep the other from stopping
+		reader.Close()
+		if st := reader.Stats(); st.Dropped != 0 || st.Delivered != n {
+			t.Fatalf("Policy %d: expected the reader to get every event once, got %+v", policy, st)
+		}
+
+		// the Hook buffers one event and holds one for its reader,
+		// its forwarder one and the native queue two more
+		if st := stalled.Stats(); st.Dropped < n-5 || st.Delivered != 0 {
+			t.Fatalf("Policy %d: expected the stalled Hook to drop the events past its buffers, got %+v", policy, st)
+		}
+		stalled.Close()
+	}
+}
+
+func TestHookStats(t *testing.T) {
+	h := New(WithBufferSize(8), WithOverflowPolicy(OverflowDropNewest))
+	if h.bufferSize != 8 || h.overflow != OverflowDropNewest {
+		t.Fatal("Options were not applied")
+	}
+
+	h.stats.dropped.Add(2)
+	if st := h.Stats(); st.Dropped != 2 || st.Delivered != 0 {
+		t.Fatal("Unexpected stats", st)
+	}
+}
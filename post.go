@@ -0,0 +1,182 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// postEvent hands the posted events to the native backend,
+// tests record them instead
+var postEvent = postNative
+
+// x11Wheel is set where the native backend is X11, see PostWheel
+var x11Wheel = runtime.GOOS != "windows" && runtime.GOOS != "darwin"
+
+// foreignSources counts the started sources by name that do not
+// read the native hook, so they would miss the events it posts
+var foreignSources = struct {
+	sync.Mutex
+	running map[string]int
+}{running: make(map[string]int)}
+
+// foreignSourceStarted adds delta to the started sources called name
+func foreignSourceStarted(name string, delta int) {
+	foreignSources.Lock()
+	defer foreignSources.Unlock()
+
+	foreignSources.running[name] += delta
+	if foreignSources.running[name] <= 0 {
+		delete(foreignSources.running, name)
+	}
+}
+
+// PostKey synthesizes pressing or releasing the key name
+//
+// The native hook reports the key with Synthetic set.
+// ctrl, shift and alt post the left key.
+//
+// Posting goes through the native backend, it fails with
+// ErrBackendUnavailable while an evdev or X11 source is started.
+func PostKey(name string, down bool) error {
+	code, err := fakeKey(name)
+	if err != nil {
+		return err
+	}
+	keycode, ok := vkKeycodes[uint16(code)]
+	if !ok {
+		return unknownKey(name, keyNames())
+	}
+
+	kind := Kind(KeyUp)
+	if down {
+		kind = KeyDown
+	}
+	return post(Event{Kind: kind, Keycode: keycode})
+}
+
+// PostMouseButton synthesizes pressing or releasing the mouse
+// button name, mleft, mright or mcenter, at x, y
+//
+// The pointer is moved to x, y first if it is elsewhere.
+func PostMouseButton(name string, down bool, x, y int16) error {
+	button, ok := mouseButton(name)
+	if !ok || isWheelButton(Code(button)) {
+		return unknownButton(name, buttonNames())
+	}
+
+	kind := Kind(MouseHold)
+	if down {
+		kind = MouseDown
+	}
+	return post(Event{Kind: kind, Button: button, X: x, Y: y})
+}
+
+// PostMouseMove synthesizes moving the pointer to x, y
+func PostMouseMove(x, y int16) error {
+	return post(Event{Kind: MouseMove, X: x, Y: y})
+}
+
+// PostWheel synthesizes turning the wheel of direction, WheelVertical
+// or WheelHorizontal, by rotation notches at x, y. The native hook
+// reports rotation back with the same sign, negative scrolls up.
+//
+// X11 has no wheel events, there the pointer is moved to x, y and the
+// wheel button clicked once per notch, like the X server reports a wheel.
+func PostWheel(direction WheelDirection, rotation int32, x, y int16) error {
+	if direction != WheelVertical && direction != WheelHorizontal {
+		return fmt.Errorf("hook: unknown wheel direction %d", direction)
+	}
+	if rotation == 0 {
+		return nil
+	}
+	if x11Wheel {
+		return postWheelClicks(direction, rotation, x, y)
+	}
+	return post(Event{Kind: MouseWheel, Direction: uint8(direction), Rotation: rotation, X: x, Y: y})
+}
+
+// postWheelClicks moves the pointer to x, y and clicks the X buttons
+// 4 and 5, or 6 and 7 for WheelHorizontal, once per notch
+func postWheelClicks(direction WheelDirection, rotation int32, x, y int16) error {
+	if err := post(Event{Kind: MouseMove, X: x, Y: y}); err != nil {
+		return err
+	}
+
+	// WheelUp and WheelLeft of hook/x11/input.h, plus one for down and right
+	button := uint16(4)
+	if direction == WheelHorizontal {
+		button = 6
+	}
+	if rotation > 0 {
+		button++
+	} else {
+		rotation = -rotation
+	}
+
+	for ; rotation > 0; rotation-- {
+		// MouseUp is EVENT_MOUSE_CLICKED, a press and a release
+		if err := post(Event{Kind: MouseUp, Button: button, X: x, Y: y}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PostHotkey synthesizes typing hotkey, like "ctrl+c", by pressing its keys
+// in order and releasing them in reverse
+//
+// Only keys can be tapped, use PostMouseButton for the buttons.
+func PostHotkey(hotkey string) error {
+	hk, err := ParseHotkey(hotkey)
+	if err != nil {
+		return err
+	}
+	if len(hk.Buttons) > 0 {
+		return unknownKey(hk.Buttons[0], keyNames())
+	}
+
+	for i, key := range hk.Keys {
+		if err := PostKey(key, true); err != nil {
+			// release what was pressed already
+			for j := i - 1; j >= 0; j-- {
+				PostKey(hk.Keys[j], false)
+			}
+			return err
+		}
+	}
+	for i := len(hk.Keys) - 1; i >= 0; i-- {
+		if err := PostKey(hk.Keys[i], false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// post hands e to the native backend
+func post(e Event) error {
+	foreignSources.Lock()
+	names := make([]string, 0, len(foreignSources.running))
+	for name := range foreignSources.running {
+		names = append(names, name)
+	}
+	foreignSources.Unlock()
+
+	if len(names) > 0 {
+		sort.Strings(names)
+		return fmt.Errorf("%w, the %s source does not see posted events", ErrBackendUnavailable, names[0])
+	}
+	return postEvent(e)
+}
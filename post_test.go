@@ -0,0 +1,274 @@
+package hook
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// recordPosts makes the Post functions record their events
+func recordPosts(t *testing.T) *[]Event {
+	t.Helper()
+	var posted []Event
+	postEvent = func(e Event) error {
+		posted = append(posted, e)
+		return nil
+	}
+	t.Cleanup(func() { postEvent = postNative })
+	return &posted
+}
+
+// windowsKeyTable reads keycode_scancode_table of hook/windows/input_c.h,
+// the VC_* code each VK code decodes to and the VK code each VC_* code,
+// by its index, posts as
+func windowsKeyTable(t *testing.T) (decode map[uint16]uint16, post map[uint16]uint16) {
+	t.Helper()
+	iohook, err := os.ReadFile("hook/iohook.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := os.ReadFile("hook/windows/input_c.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vc := make(map[string]uint16)
+	for _, m := range regexp.MustCompile(`(?m)^#define\s+(VC_\w+)\s+0x([0-9A-Fa-f]+)`).FindAllStringSubmatch(string(iohook), -1) {
+		n, _ := strconv.ParseUint(m[2], 16, 16)
+		vc[m[1]] = uint16(n)
+	}
+
+	table := strings.SplitN(string(input), "keycode_scancode_table[][2] = {", 2)[1]
+	table = strings.SplitN(table, "};", 2)[0]
+	rows := regexp.MustCompile(`/\*\s*(\d+)\s*\*/\s*\{\s*(\w+)\s*,\s*(\w+)\s*\},?\s*//(.*)`).FindAllStringSubmatch(table, -1)
+	if len(rows) != 256 {
+		t.Fatal("Expected 256 rows in keycode_scancode_table, got", len(rows))
+	}
+
+	// the comments name the VK code of each index
+	vk := make(map[string]uint16)
+	for i, m := range rows {
+		for _, name := range regexp.MustCompile(`VK_\w+`).FindAllString(m[4], -1) {
+			if _, ok := vk[name]; !ok {
+				vk[name] = uint16(i)
+			}
+		}
+	}
+
+	decode, post = make(map[uint16]uint16), make(map[uint16]uint16)
+	for i, m := range rows {
+		if code, ok := vc[m[2]]; ok && code != 0 {
+			decode[uint16(i)] = code
+		}
+		if code, ok := vk[m[3]]; ok {
+			post[uint16(i)] = code
+		} else if n, err := strconv.ParseUint(m[3], 0, 16); err == nil {
+			post[uint16(i)] = uint16(n)
+		} else {
+			t.Fatalf("Unknown VK code %s in row %d", m[3], i)
+		}
+	}
+	return decode, post
+}
+
+func TestPostKeyRoundTrip(t *testing.T) {
+	decode, post := windowsKeyTable(t)
+	posted := recordPosts(t)
+
+	for _, name := range keyNames() {
+		if _, ok := mouseButton(name); ok {
+			continue
+		}
+		code, err := fakeKey(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		*posted = (*posted)[:0]
+		if err := PostKey(name, true); err != nil {
+			if _, ok := decode[uint16(code)]; ok {
+				t.Errorf("PostKey(%q) failed on a key Windows decodes: %v", name, err)
+			}
+			continue
+		}
+		if len(*posted) != 1 {
+			t.Fatalf("Expected PostKey(%q) to post one event, got %v", name, *posted)
+		}
+
+		// scancode_to_keycode looks the extended codes up at an offset
+		keycode := (*posted)[0].Keycode
+		index := keycode
+		if index >= 128 {
+			index = index&0x7F | 0x80
+		}
+		if code == 0xE0 {
+			// kp_enter has no VK code, hook_post_event fails on it
+			if post[index] != 0 {
+				t.Errorf("Expected kp_enter to have no VK code, got %#x", post[index])
+			}
+			continue
+		}
+		if post[index] != uint16(code) {
+			t.Errorf("PostKey(%q) posted %#x, the VK code %#x, instead of %#x", name, keycode, post[index], code)
+		}
+		if decode[post[index]] != keycode {
+			t.Errorf("PostKey(%q) posted %#x, decoded back as %#x", name, keycode, decode[post[index]])
+		}
+	}
+}
+
+func TestPostUnknown(t *testing.T) {
+	var unknown *UnknownKeyError
+	if err := PostKey("ctrll", true); !errors.As(err, &unknown) {
+		t.Fatal("Expected an UnknownKeyError, got", err)
+	}
+	if err := PostMouseButton("wheelUp", true, 0, 0); !errors.As(err, &unknown) || !unknown.Button {
+		t.Fatal("Expected an unknown button, got", err)
+	}
+	if err := PostHotkey("ctrl+mleft"); !errors.As(err, &unknown) {
+		t.Fatal("Expected PostHotkey to refuse buttons, got", err)
+	}
+}
+
+func TestPostWithForeignSource(t *testing.T) {
+	foreignSourceStarted("evdev", 1)
+	err := PostMouseMove(10, 20)
+	foreignSourceStarted("evdev", -1)
+
+	if !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatal("Expected ErrBackendUnavailable while an evdev source runs, got", err)
+	}
+
+	foreignSources.Lock()
+	defer foreignSources.Unlock()
+	if len(foreignSources.running) != 0 {
+		t.Fatal("Expected the stopped source to be forgotten, got", foreignSources.running)
+	}
+}
+
+func TestPostWheel(t *testing.T) {
+	posted := recordPosts(t)
+	defer func(old bool) { x11Wheel = old }(x11Wheel)
+	x11Wheel = false
+
+	if err := PostWheel(WheelHorizontal, -2, 10, 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := PostWheel(WheelVertical, 0, 10, 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := PostWheel(WheelDirection(0), 1, 10, 20); err == nil {
+		t.Fatal("Expected an unknown direction to fail")
+	}
+
+	want := []Event{{Kind: MouseWheel, Direction: uint8(WheelHorizontal), Rotation: -2, X: 10, Y: 20}}
+	if !reflect.DeepEqual(*posted, want) {
+		t.Fatalf("Expected %v, got %v", want, *posted)
+	}
+}
+
+func TestPostSequence(t *testing.T) {
+	posted := recordPosts(t)
+
+	if err := PostHotkey("ctrl+c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PostMouseButton("mright", true, 30, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := PostMouseButton("mright", false, 30, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := PostMouseMove(-5, 600); err != nil {
+		t.Fatal(err)
+	}
+
+	// VC_CONTROL_L and VC_C
+	want := []Event{
+		{Kind: KeyDown, Keycode: 0x001D},
+		{Kind: KeyDown, Keycode: 0x002E},
+		{Kind: KeyUp, Keycode: 0x002E},
+		{Kind: KeyUp, Keycode: 0x001D},
+		{Kind: MouseDown, Button: 2, X: 30, Y: 40},
+		{Kind: MouseHold, Button: 2, X: 30, Y: 40},
+		{Kind: MouseMove, X: -5, Y: 600},
+	}
+	if !reflect.DeepEqual(*posted, want) {
+		t.Fatalf("Expected\n%v\ngot\n%v", want, *posted)
+	}
+}
+
+func TestPostHotkeyReleasesOnError(t *testing.T) {
+	var posted []Event
+	postEvent = func(e Event) error {
+		// the backend refuses the third key
+		if e.Kind == KeyDown && e.Keycode == 0x002E {
+			return ErrHookFailed
+		}
+		posted = append(posted, e)
+		return nil
+	}
+	defer func() { postEvent = postNative }()
+
+	if err := PostHotkey("ctrl+shift+c"); !errors.Is(err, ErrHookFailed) {
+		t.Fatal("Expected the error of the backend, got", err)
+	}
+
+	// VC_CONTROL_L and VC_SHIFT_L
+	want := []Event{
+		{Kind: KeyDown, Keycode: 0x001D},
+		{Kind: KeyDown, Keycode: 0x002A},
+		{Kind: KeyUp, Keycode: 0x002A},
+		{Kind: KeyUp, Keycode: 0x001D},
+	}
+	if !reflect.DeepEqual(posted, want) {
+		t.Fatalf("Expected\n%v\ngot\n%v", want, posted)
+	}
+}
+
+func TestPostWheelX11(t *testing.T) {
+	posted := recordPosts(t)
+	defer func(old bool) { x11Wheel = old }(x11Wheel)
+	x11Wheel = true
+
+	if err := PostWheel(WheelVertical, -3, 7, 8); err != nil {
+		t.Fatal(err)
+	}
+	if err := PostWheel(WheelHorizontal, 2, 9, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// one click of WheelUp per notch, then of WheelRight
+	want := []Event{
+		{Kind: MouseMove, X: 7, Y: 8},
+		{Kind: MouseUp, Button: 4, X: 7, Y: 8},
+		{Kind: MouseUp, Button: 4, X: 7, Y: 8},
+		{Kind: MouseUp, Button: 4, X: 7, Y: 8},
+		{Kind: MouseMove, X: 9, Y: 10},
+		{Kind: MouseUp, Button: 7, X: 9, Y: 10},
+		{Kind: MouseUp, Button: 7, X: 9, Y: 10},
+	}
+	if !reflect.DeepEqual(*posted, want) {
+		t.Fatalf("Expected\n%v\ngot\n%v", want, *posted)
+	}
+
+	// the first failing click ends the wheel
+	*posted = (*posted)[:0]
+	postEvent = func(e Event) error {
+		*posted = append(*posted, e)
+		if len(*posted) == 3 {
+			return ErrHookFailed
+		}
+		return nil
+	}
+	if err := PostWheel(WheelVertical, 5, 0, 0); !errors.Is(err, ErrHookFailed) {
+		t.Fatal("Expected the error of the backend, got", err)
+	}
+	if len(*posted) != 3 {
+		t.Fatal("Expected the wheel to stop at the failed click, got", *posted)
+	}
+}
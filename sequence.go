@@ -0,0 +1,244 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+)
+
+// DefaultSequenceTimeout is the longest pause between two strokes
+// of a key sequence unless WithSequenceTimeout says otherwise
+const DefaultSequenceTimeout = time.Second
+
+// sequence is the state of a key sequence binding like "ctrl+k ctrl+c"
+type sequence struct {
+	strokes []combination
+	timeout time.Duration
+	onAbort func(Event)
+
+	// pos is the index of the next stroke, a sequence
+	// with pos > 0 is waiting for its next stroke
+	pos   int
+	timer *time.Timer
+	gen   int
+}
+
+// SequenceOption configures a key sequence registered
+// with RegisterSequence
+type SequenceOption func(*sequence)
+
+// WithSequenceTimeout sets the longest pause between two strokes
+func WithSequenceTimeout(d time.Duration) SequenceOption {
+	return func(s *sequence) {
+		s.timeout = d
+	}
+}
+
+// WithSequenceAbort sets a callback for a started sequence that breaks off
+//
+// It gets the key event that did not fit the next stroke, or a zero
+// Event if the timeout expired. On timeout it is called from its own
+// goroutine instead of the Process one.
+func WithSequenceAbort(cb func(Event)) SequenceOption {
+	return func(s *sequence) {
+		s.onAbort = cb
+	}
+}
+
+// ParseSequence parses a key sequence of space separated hotkeys,
+// for example "ctrl+k ctrl+c"
+//
+// Spaces around the + and - separators belong to a single stroke,
+// so "ctrl + k ctrl + c" is read the same way.
+func ParseSequence(s string) ([]Hotkey, error) {
+	strokes := []Hotkey{}
+	for _, span := range strokeSpans(s) {
+		hk, err := ParseHotkey(s[span[0]:span[1]])
+		if err != nil {
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				perr.Input = s
+				perr.Offset += span[0]
+			}
+			return nil, err
+		}
+		strokes = append(strokes, hk)
+	}
+
+	if len(strokes) == 0 {
+		return nil, &ParseError{Input: s, Err: ErrEmptyHotkey}
+	}
+	return strokes, nil
+}
+
+// strokeSpans splits s on the spaces that are not next to a separator
+// and returns the start and end offsets of every stroke
+func strokeSpans(s string) [][2]int {
+	spans := [][2]int{}
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		space := i < len(s) && unicode.IsSpace(rune(s[i]))
+		if i < len(s) && !space {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+
+		// look around the run of spaces for a separator
+		j := i
+		for j < len(s) && unicode.IsSpace(rune(s[j])) {
+			j++
+		}
+		if i < len(s) && j < len(s) && (isSeparator(s[i-1]) || isSeparator(s[j])) {
+			i = j - 1
+			continue
+		}
+
+		spans = append(spans, [2]int{start, i})
+		start = -1
+	}
+
+	return spans
+}
+
+func isSeparator(c byte) bool {
+	return c == '+' || c == '-'
+}
+
+// RegisterSequence registers a key sequence on the default Hook
+func RegisterSequence(seq string, cb func(Event), opts ...SequenceOption) (*Binding, error) {
+	return defaultHook.RegisterSequence(seq, cb, opts...)
+}
+
+// RegisterSequence registers an ordered key sequence like "ctrl+k ctrl+c",
+// see ParseSequence
+//
+// cb is called on the key down that completes the last stroke. While a
+// sequence waits for its next stroke, key downs of the keys of that
+// stroke keep it waiting, any other key down breaks it off. Sequences
+// are matched next to the combination bindings, which keep firing.
+func (h *Hook) RegisterSequence(seq string, cb func(Event), opts ...SequenceOption) (*Binding, error) {
+	hotkeys, err := ParseSequence(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sequence{timeout: DefaultSequenceTimeout}
+	for _, hk := range hotkeys {
+		if len(hk.Buttons) != 0 {
+			return nil, fmt.Errorf("hook: sequence %q has mouse buttons, only keys are supported", seq)
+		}
+
+		codes, _, err := h.codes(KeyDown, hk.Keys)
+		if err != nil {
+			return nil, err
+		}
+		s.strokes = append(s.strokes, codes)
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	b := &Binding{hook: h, when: KeyDown, cb: cb, seq: s}
+
+	h.stateMu.Lock()
+	h.add(b)
+	h.stateMu.Unlock()
+
+	h.log("registered sequence %v as %v\n", seq, s.strokes)
+	return b, nil
+}
+
+// advanceSequences moves every sequence forward with a key down
+// and returns the callbacks to call, the caller must hold stateMu
+func (h *Hook) advanceSequences(ev Event) (cbs []func(Event)) {
+	code := Code(ev.Rawcode)
+	for b, s := range h.sequences {
+		if s.pos > 0 && !s.matches(s.pos, code, h.pressed) && !s.waitsFor(code) {
+			h.log("sequence %v broken by %v\n", s.strokes, code)
+			s.reset()
+			if s.onAbort != nil {
+				cbs = append(cbs, s.onAbort)
+			}
+		}
+
+		if !s.matches(s.pos, code, h.pressed) {
+			continue
+		}
+
+		s.pos++
+		if s.pos < len(s.strokes) {
+			h.log("sequence %v waits for stroke %v\n", s.strokes, s.pos)
+			s.wait(h)
+			continue
+		}
+
+		h.log("calling sequence %v\n", s.strokes)
+		s.reset()
+		cbs = append(cbs, b.cb)
+	}
+
+	return
+}
+
+// matches reports whether a key down of code completes stroke i
+func (s *sequence) matches(i int, code Code, pressed map[Code]bool) bool {
+	return s.strokes[i].hasKey(code) && allPressed(pressed, s.strokes[i])
+}
+
+// waitsFor reports whether code belongs to the next stroke,
+// like the ctrl of "ctrl+c" pressed before the c
+func (s *sequence) waitsFor(code Code) bool {
+	return s.strokes[s.pos].hasKey(code)
+}
+
+// wait (re)starts the timeout for the next stroke,
+// the caller must hold stateMu
+func (s *sequence) wait(h *Hook) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	s.gen++
+	gen := s.gen
+	s.timer = time.AfterFunc(s.timeout, func() {
+		h.stateMu.Lock()
+		expired := s.gen == gen && s.pos > 0
+		if expired {
+			s.reset()
+		}
+		h.stateMu.Unlock()
+
+		if expired && s.onAbort != nil {
+			h.log("sequence %v timed out\n", s.strokes)
+			s.onAbort(Event{})
+		}
+	})
+}
+
+// reset forgets the progress of the sequence,
+// the caller must hold stateMu
+func (s *sequence) reset() {
+	s.pos = 0
+	s.gen++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
@@ -0,0 +1,122 @@
+package hook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSequence(t *testing.T) {
+	cases := map[string][]string{
+		"ctrl+k ctrl+c":       {"ctrl+k", "ctrl+c"},
+		"ctrl + k  ctrl - c":  {"ctrl+k", "ctrl+c"},
+		"  g g ":              {"g", "g"},
+		"ctrl+x ctrl+s shift": {"ctrl+x", "ctrl+s", "shift"},
+	}
+
+	for input, want := range cases {
+		strokes, err := ParseSequence(input)
+		if err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if len(strokes) != len(want) {
+			t.Fatalf("%q: expected %v, got %v", input, want, strokes)
+		}
+		for i := range want {
+			if strokes[i].String() != want[i] {
+				t.Fatalf("%q: expected %v, got %v", input, want, strokes)
+			}
+		}
+	}
+
+	_, err := ParseSequence("ctrl+k ctrl+foo")
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Token != "foo" || perr.Offset != 12 {
+		t.Fatal("Expected a ParseError for foo at offset 12, got", err)
+	}
+}
+
+// sequenceEvents feeds key downs and ups of keys to ch
+func sequenceEvents(ch chan Event, keys ...string) {
+	for _, key := range keys {
+		ch <- Event{Rawcode: Keycode[key], Kind: KeyDown}
+	}
+	for _, key := range keys {
+		ch <- Event{Rawcode: Keycode[key], Kind: KeyUp}
+	}
+}
+
+func TestSequence(t *testing.T) {
+	h := New()
+	got := make(chan string, 8)
+	if _, err := h.RegisterSequence("ctrl+k ctrl+c", func(e Event) {
+		got <- "comment"
+	}, WithSequenceAbort(func(e Event) {
+		got <- "abort"
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.RegisterSequence("ctrl+k ctrl+u", func(e Event) {
+		got <- "uncomment"
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	sequenceEvents(ch, "ctrl", "k")
+	sequenceEvents(ch, "ctrl", "c")
+	sequenceEvents(ch, "ctrl", "k")
+	sequenceEvents(ch, "ctrl", "u")
+	sequenceEvents(ch, "ctrl", "k")
+	sequenceEvents(ch, "x")
+	close(ch)
+	<-done
+	close(got)
+
+	// callbacks of the same event run in no particular order
+	calls := map[string]int{}
+	for call := range got {
+		calls[call]++
+	}
+	if calls["comment"] != 1 || calls["uncomment"] != 1 || calls["abort"] != 2 {
+		t.Fatal("Expected comment, uncomment and two aborts, got", calls)
+	}
+}
+
+func TestSequenceTimeout(t *testing.T) {
+	h := New()
+	aborted := make(chan Event, 1)
+	matched := make(chan bool, 1)
+	if _, err := h.RegisterSequence("g g", func(e Event) {
+		matched <- true
+	}, WithSequenceTimeout(50*time.Millisecond), WithSequenceAbort(func(e Event) {
+		aborted <- e
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	defer close(ch)
+	h.Process(ch)
+
+	sequenceEvents(ch, "g")
+
+	select {
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timeout waiting for the sequence to be aborted")
+	case e := <-aborted:
+		if e.Kind != 0 {
+			t.Fatal("Expected a zero Event on timeout, got", e)
+		}
+	}
+
+	// the second g starts the sequence over
+	sequenceEvents(ch, "g")
+	select {
+	case <-matched:
+		t.Fatal("Sequence matched after its timeout")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Source produces the events of a Hook, the native hook by default
+//
+// A Source can be started again after it was stopped.
+type Source interface {
+	// Start begins producing events and returns once the source
+	// is ready, or with the error that kept it from starting
+	Start(ctx context.Context) error
+	// Events returns the channel of the running source,
+	// it is closed by Stop
+	Events() <-chan Event
+	// Stop ends the source
+	Stop()
+}
+
+// WithSource makes a Hook read its events from src,
+// nil selects the native hook
+func WithSource(src Source) Option {
+	return func(h *Hook) {
+		if src == nil {
+			src = NewNativeSource()
+		}
+		h.source = src
+	}
+}
+
+// SetSource changes the Source of the default Hook
+func SetSource(src Source) error {
+	return defaultHook.SetSource(src)
+}
+
+// SetSource changes the Source of h, nil selects the native hook
+//
+// It returns ErrHookRunning if h is started.
+func (h *Hook) SetSource(src Source) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.session != nil {
+		return ErrHookRunning
+	}
+
+	WithSource(src)(h)
+	return nil
+}
+
+// NewNativeSource returns a Source reading the native hook
+//
+// The native hook is shared by the whole process, it runs
+// while at least one native source is started.
+func NewNativeSource() Source {
+	return &nativeSource{}
+}
+
+// bufferedSource is a Source buffering its events for each reader,
+// like the native one, its buffer follows the Hook reading it
+type bufferedSource interface {
+	buffer(size int, policy OverflowPolicy, st *stats)
+}
+
+type nativeSource struct {
+	mu sync.Mutex
+	// queue buffers the events of dispatch for the reader of Events,
+	// it drops them once full so a Hook that is not read holds up no other
+	queue *session
+
+	// size, policy and stats of the queue, set by buffer
+	size   int
+	policy OverflowPolicy
+	stats  *stats
+}
+
+// buffer makes the next queue of n buffer size events following policy
+// and count the dropped ones in st
+func (n *nativeSource) buffer(size int, policy OverflowPolicy, st *stats) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.size, n.policy, n.stats = size, policy, st
+}
+
+func (n *nativeSource) Start(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.queue != nil {
+		return nil
+	}
+
+	n.open()
+	if err := startNative(ctx, n); err != nil {
+		n.shut()
+		return err
+	}
+
+	return nil
+}
+
+func (n *nativeSource) Events() <-chan Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.queue == nil {
+		return nil
+	}
+	return n.queue.ev
+}
+
+func (n *nativeSource) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.queue == nil {
+		return
+	}
+
+	stopNative(n)
+	n.shut()
+}
+
+// open sets up the queue of n before it is subscribed
+func (n *nativeSource) open() {
+	size, st := n.size, n.stats
+	if size == 0 {
+		size = DefaultBufferSize
+	}
+	if st == nil {
+		st = &stats{}
+	}
+	n.queue = newRelay(size, n.policy, st)
+}
+
+// shut closes the events of n once it is unsubscribed
+func (n *nativeSource) shut() {
+	close(n.queue.done)
+	n.queue.close()
+	close(n.queue.ev)
+	n.queue = nil
+}
+
+// native is the process wide libuiohook instance. The C library only
+// supports a single hook, so every started Hook subscribes to it and
+// the hook runs while at least one subscriber is left.
+var native = struct {
+	sync.Mutex
+	subscribers map[*nativeSource]bool
+
+	// enabled is closed once the C hook reported EVENT_HOOK_ENABLED
+	enabled     chan struct{}
+	enabledOnce *sync.Once
+
+	// lifecycle serializes starting and stopping the C hook,
+	// running is closed once hook_run returned
+	lifecycle  sync.Mutex
+	running    chan struct{}
+	stop       chan struct{}
+	delivering sync.WaitGroup
+}{subscribers: make(map[*nativeSource]bool)}
+
+// subscribe adds src to the subscribers of the native hook and reports
+// whether it is the first one, which has to start the hook. A later one
+// joins a hook that is already enabled, so it gets a HookEnabled of its own.
+func subscribe(src *nativeSource) bool {
+	native.Lock()
+	defer native.Unlock()
+
+	if len(native.subscribers) == 0 {
+		native.subscribers[src] = true
+		return true
+	}
+
+	// queued before dispatch sees src, so it comes first
+	src.queue.send(Event{Kind: HookEnabled, When: time.Now()})
+	native.subscribers[src] = true
+	return false
+}
+
+// dispatch fans a native event out to every subscribed source
+//
+// The events are queued outside of native, so a subscriber
+// can not hold up starting or stopping the others.
+func dispatch(e Event) {
+	native.Lock()
+	if e.Kind == HookEnabled && native.enabledOnce != nil {
+		native.enabledOnce.Do(func() {
+			close(native.enabled)
+		})
+	}
+
+	queues := make([]*session, 0, len(native.subscribers))
+	for src := range native.subscribers {
+		queues = append(queues, src.queue)
+	}
+	native.Unlock()
+
+	for _, q := range queues {
+		q.send(e)
+	}
+}
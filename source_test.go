@@ -0,0 +1,291 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// startFake starts a FakeSource with a fixed clock and skips HookEnabled
+func startFake(t *testing.T) *FakeSource {
+	t.Helper()
+
+	src := NewFakeSource()
+	src.Clock = func() time.Time { return time.Unix(1, 0) }
+	if err := src.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(src.Stop)
+
+	if e := <-src.Events(); e.Kind != HookEnabled {
+		t.Fatal("Expected HookEnabled first, got", e)
+	}
+	return src
+}
+
+// takeFake returns the n events buffered by src
+func takeFake(t *testing.T, src *FakeSource, n int) []Event {
+	t.Helper()
+
+	events := make([]Event, n)
+	for i := range events {
+		select {
+		case events[i] = <-src.Events():
+		default:
+			t.Fatalf("Expected %d events, got %d", n, i)
+		}
+	}
+	select {
+	case e := <-src.Events():
+		t.Fatal("Unexpected event", e)
+	default:
+	}
+	return events
+}
+
+func TestFakeSourceTap(t *testing.T) {
+	src := startFake(t)
+	if err := src.Tap("ctrl+shift+c"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		kind Kind
+		key  string
+		mask Modifiers
+	}{
+		{KeyDown, "left_control", ModCtrlLeft},
+		{KeyDown, "left_shift", ModCtrlLeft | ModShiftLeft},
+		{KeyDown, "c", ModCtrlLeft | ModShiftLeft},
+		{KeyUp, "c", ModCtrlLeft | ModShiftLeft},
+		{KeyUp, "left_shift", ModCtrlLeft},
+		{KeyUp, "left_control", 0},
+	}
+	for i, e := range takeFake(t, src, len(want)) {
+		w := want[i]
+		if e.Kind != w.kind || e.Rawcode != Keycode[w.key] || Modifiers(e.Mask) != w.mask {
+			t.Errorf("Event %d: got %v with mask %v, want %v of %s with mask %v",
+				i, e, Modifiers(e.Mask), w.kind, w.key, w.mask)
+		}
+		if !e.When.Equal(time.Unix(1, 0)) {
+			t.Errorf("Event %d: expected the time of Clock, got %v", i, e.When)
+		}
+	}
+}
+
+func TestFakeSourceClick(t *testing.T) {
+	src := startFake(t)
+	if err := src.Click("mright", 10, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		kind Kind
+		mask Modifiers
+	}{
+		{MouseMove, 0},
+		{MouseDown, ModButton2},
+		{MouseHold, 0},
+		{MouseUp, 0},
+	}
+	for i, e := range takeFake(t, src, len(want)) {
+		if e.Kind != want[i].kind || Modifiers(e.Mask) != want[i].mask || e.X != 10 || e.Y != 20 {
+			t.Errorf("Event %d: got %v with mask %v", i, e, Modifiers(e.Mask))
+		}
+		if e.Kind != MouseMove && e.Button != MouseMap["right"] {
+			t.Errorf("Event %d: expected the right button, got %v", i, e.Button)
+		}
+	}
+
+	// the pointer did not move, so there is no move event
+	if err := src.Click("left", 10, 20); err != nil {
+		t.Fatal(err)
+	}
+	takeFake(t, src, 3)
+
+	if err := src.PressButton("left"); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.MoveMouse(11, 20); err != nil {
+		t.Fatal(err)
+	}
+	if e := takeFake(t, src, 2)[1]; e.Kind != MouseDrag {
+		t.Fatal("Expected a drag while a button is held, got", e)
+	}
+}
+
+func TestFakeSourceScroll(t *testing.T) {
+	src := startFake(t)
+	if err := src.Scroll(2, -1); err != nil {
+		t.Fatal(err)
+	}
+
+	events := takeFake(t, src, 2)
+	up, ok := events[0].Wheel()
+	if !ok || up.Direction != WheelVertical || up.Rotation != -1 || up.Type != WheelUnitScroll {
+		t.Error("Expected a vertical scroll up, got", events[0])
+	}
+	right, ok := events[1].Wheel()
+	if !ok || right.Direction != WheelHorizontal || right.Rotation != 2 {
+		t.Error("Expected a horizontal scroll right, got", events[1])
+	}
+}
+
+func TestFakeSourceErrors(t *testing.T) {
+	src := NewFakeSource()
+	if err := src.PressKey("a"); !errors.Is(err, ErrSourceStopped) {
+		t.Fatal("Expected ErrSourceStopped before Start, got", err)
+	}
+
+	src = startFake(t)
+	var unknown *UnknownKeyError
+	if err := src.PressKey("ctrl", "nope"); !errors.As(err, &unknown) {
+		t.Fatal("Expected an UnknownKeyError, got", err)
+	}
+	if err := src.Click("wheelUp", 0, 0); !errors.As(err, &unknown) || !unknown.Button {
+		t.Fatal("Expected an unknown button, got", err)
+	}
+	// nothing is sent for a list with an unknown key
+	takeFake(t, src, 0)
+
+	src.Stop()
+	if err := src.Tap("a"); !errors.Is(err, ErrSourceStopped) {
+		t.Fatal("Expected ErrSourceStopped after Stop, got", err)
+	}
+	if err := src.Start(context.Background()); err != nil {
+		t.Fatal("Expected the source to start again, got", err)
+	}
+}
+
+func TestHookWithFakeSource(t *testing.T) {
+	src := NewFakeSource()
+	h := New(WithSource(src))
+	fired := make(chan string, 2)
+
+	if _, err := h.RegisterHotkey(KeyDown, "ctrl+c", func(e Event) {
+		fired <- "ctrl+c"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.RegisterHotkey(MouseWheel, "shift+wheelDown", func(e Event) {
+		fired <- "shift+wheelDown"
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Run(ctx)
+
+	waitStarted(t, src)
+	if err := h.SetSource(nil); !errors.Is(err, ErrHookRunning) {
+		t.Fatal("Expected ErrHookRunning, got", err)
+	}
+
+	if err := src.Tap("ctrl+c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Tap("shift+wheelDown"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"ctrl+c", "shift+wheelDown"} {
+		select {
+		case <-time.After(TIMEOUT):
+			t.Fatal("Timeout waiting for", want)
+		case got := <-fired:
+			if got != want {
+				t.Fatalf("Expected %s, got %s", want, got)
+			}
+		}
+	}
+}
+
+func TestHookWithFakeSourceSides(t *testing.T) {
+	src := NewFakeSource()
+	h := New(WithSource(src))
+	fired := make(chan string, 3)
+
+	for _, hotkey := range []string{"lctrl+c", "lcmd+k"} {
+		if _, err := h.RegisterHotkey(KeyDown, hotkey, func(e Event) {
+			fired <- hotkey
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Run(ctx)
+	waitStarted(t, src)
+
+	// the generic modifiers are pressed as their left keys
+	for _, hotkey := range []string{"rctrl+c", "ctrl+c", "cmd+k"} {
+		if err := src.Tap(hotkey); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []string{"lctrl+c", "lcmd+k"} {
+		select {
+		case <-time.After(TIMEOUT):
+			t.Fatal("Timeout waiting for", want)
+		case got := <-fired:
+			if got != want {
+				t.Fatalf("Expected %s, got %s", want, got)
+			}
+		}
+	}
+}
+
+// waitStarted waits until src is started
+func waitStarted(t *testing.T, src *FakeSource) {
+	t.Helper()
+
+	timeout := time.After(TIMEOUT)
+	for src.Events() == nil {
+		select {
+		case <-timeout:
+			t.Fatal("Timeout waiting for the source to start")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestNativeLateSubscriber(t *testing.T) {
+	first, late := &nativeSource{}, &nativeSource{}
+	first.open()
+	late.open()
+	t.Cleanup(func() {
+		native.Lock()
+		delete(native.subscribers, first)
+		delete(native.subscribers, late)
+		native.Unlock()
+		first.shut()
+		late.shut()
+	})
+
+	if !subscribe(first) {
+		t.Fatal("Expected the first subscriber to start the hook")
+	}
+	if subscribe(late) {
+		t.Fatal("Expected the late subscriber to join the running hook")
+	}
+	dispatch(Event{Kind: KeyDown, Rawcode: Keycode["a"]})
+
+	// the first one got the HookEnabled of the C hook
+	if e := <-first.Events(); e.Kind != KeyDown {
+		t.Fatal("Expected no HookEnabled of subscribe for the first subscriber, got", e)
+	}
+	for _, kind := range []Kind{HookEnabled, KeyDown} {
+		select {
+		case e := <-late.Events():
+			if e.Kind != kind {
+				t.Fatalf("Expected %v, got %v", kind, e)
+			}
+		case <-time.After(TIMEOUT):
+			t.Fatal("Timeout waiting for", kind)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+// vkKeycodes maps WindowsVKCodes to the VC_* codes of hook/iohook.h
+// that hook_post_event takes, as keycode_scancode_table of
+// hook/windows/input_c.h decodes them. The VC_* codes are the same
+// on every backend, each one maps them to its own keys.
+var vkKeycodes = map[uint16]uint16{
+	0x08: 0x000E, // VC_BACKSPACE
+	0x09: 0x000F, // VC_TAB
+	0x0D: 0x001C, // VC_ENTER
+	0x10: 0x002A, // VC_SHIFT_L
+	0x11: 0x001D, // VC_CONTROL_L
+	0x12: 0x0038, // VC_ALT_L
+	0x13: 0x0E45, // VC_PAUSE
+	0x14: 0x003A, // VC_CAPS_LOCK
+	0x1B: 0x0001, // VC_ESCAPE
+	0x20: 0x0039, // VC_SPACE
+	0x21: 0x0E49, // VC_PAGE_UP
+	0x22: 0x0E51, // VC_PAGE_DOWN
+	0x23: 0x0E4F, // VC_END
+	0x24: 0x0E47, // VC_HOME
+	0x25: 0xE04B, // VC_LEFT
+	0x26: 0xE048, // VC_UP
+	0x27: 0xE04D, // VC_RIGHT
+	0x28: 0xE050, // VC_DOWN
+	0x2C: 0x0E37, // VC_PRINTSCREEN
+	0x2D: 0x0E52, // VC_INSERT
+	0x2E: 0x0E53, // VC_DELETE
+	0x30: 0x000B, // VC_0
+	0x31: 0x0002, // VC_1
+	0x32: 0x0003, // VC_2
+	0x33: 0x0004, // VC_3
+	0x34: 0x0005, // VC_4
+	0x35: 0x0006, // VC_5
+	0x36: 0x0007, // VC_6
+	0x37: 0x0008, // VC_7
+	0x38: 0x0009, // VC_8
+	0x39: 0x000A, // VC_9
+	0x41: 0x001E, // VC_A
+	0x42: 0x0030, // VC_B
+	0x43: 0x002E, // VC_C
+	0x44: 0x0020, // VC_D
+	0x45: 0x0012, // VC_E
+	0x46: 0x0021, // VC_F
+	0x47: 0x0022, // VC_G
+	0x48: 0x0023, // VC_H
+	0x49: 0x0017, // VC_I
+	0x4A: 0x0024, // VC_J
+	0x4B: 0x0025, // VC_K
+	0x4C: 0x0026, // VC_L
+	0x4D: 0x0032, // VC_M
+	0x4E: 0x0031, // VC_N
+	0x4F: 0x0018, // VC_O
+	0x50: 0x0019, // VC_P
+	0x51: 0x0010, // VC_Q
+	0x52: 0x0013, // VC_R
+	0x53: 0x001F, // VC_S
+	0x54: 0x0014, // VC_T
+	0x55: 0x0016, // VC_U
+	0x56: 0x002F, // VC_V
+	0x57: 0x0011, // VC_W
+	0x58: 0x002D, // VC_X
+	0x59: 0x0015, // VC_Y
+	0x5A: 0x002C, // VC_Z
+	0x5B: 0x0E5B, // VC_META_L
+	0x5C: 0x0E5C, // VC_META_R
+	0x60: 0x0052, // VC_KP_0
+	0x61: 0x004F, // VC_KP_1
+	0x62: 0x0050, // VC_KP_2
+	0x63: 0x0051, // VC_KP_3
+	0x64: 0x004B, // VC_KP_4
+	0x65: 0x004C, // VC_KP_5
+	0x66: 0x004D, // VC_KP_6
+	0x67: 0x0047, // VC_KP_7
+	0x68: 0x0048, // VC_KP_8
+	0x69: 0x0049, // VC_KP_9
+	0x6A: 0x0037, // VC_KP_MULTIPLY
+	0x6B: 0x004E, // VC_KP_ADD
+	0x6D: 0x004A, // VC_KP_SUBTRACT
+	0x6E: 0x0053, // VC_KP_SEPARATOR
+	0x6F: 0x0E35, // VC_KP_DIVIDE
+	0x70: 0x003B, // VC_F1
+	0x71: 0x003C, // VC_F2
+	0x72: 0x003D, // VC_F3
+	0x73: 0x003E, // VC_F4
+	0x74: 0x003F, // VC_F5
+	0x75: 0x0040, // VC_F6
+	0x76: 0x0041, // VC_F7
+	0x77: 0x0042, // VC_F8
+	0x78: 0x0043, // VC_F9
+	0x79: 0x0044, // VC_F10
+	0x7A: 0x0057, // VC_F11
+	0x7B: 0x0058, // VC_F12
+	0x90: 0x0045, // VC_NUM_LOCK
+	0x91: 0x0046, // VC_SCROLL_LOCK
+	0xA0: 0x002A, // VC_SHIFT_L
+	0xA1: 0x0036, // VC_SHIFT_R
+	0xA2: 0x001D, // VC_CONTROL_L
+	0xA3: 0x0E1D, // VC_CONTROL_R
+	0xA4: 0x0038, // VC_ALT_L
+	0xA5: 0x0E38, // VC_ALT_R
+	0xBA: 0x0027, // VC_SEMICOLON
+	0xBB: 0x000D, // VC_EQUALS
+	0xBC: 0x0033, // VC_COMMA
+	0xBD: 0x000C, // VC_MINUS
+	0xBE: 0x0034, // VC_PERIOD
+	0xBF: 0x0035, // VC_SLASH
+	0xC0: 0x0029, // VC_BACKQUOTE
+	0xDB: 0x001A, // VC_OPEN_BRACKET
+	0xDC: 0x002B, // VC_BACK_SLASH
+	0xDD: 0x001B, // VC_CLOSE_BRACKET
+	0xDE: 0x0028, // VC_QUOTE
+
+	// kp_enter has no VK code of its own, Windows reports it as
+	// VK_RETURN with the extended flag and can not post it
+	0xE0: 0x0E1C, // VC_KP_ENTER
+}
@@ -0,0 +1,157 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+// WheelType tells how the system scrolls for a MouseWheel event,
+// see WHEEL_*_SCROLL in hook/iohook.h
+type WheelType uint8
+
+const (
+	// WheelUnitScroll scrolls Amount units, usually lines, per rotation
+	WheelUnitScroll WheelType = 1
+	// WheelBlockScroll scrolls a page per rotation
+	WheelBlockScroll WheelType = 2
+)
+
+// WheelDirection is the axis of a MouseWheel event,
+// see WHEEL_*_DIRECTION in hook/iohook.h
+type WheelDirection uint8
+
+const (
+	WheelVertical   WheelDirection = 3
+	WheelHorizontal WheelDirection = 4
+)
+
+// WheelEvent holds the decoded fields of a MouseWheel event
+type WheelEvent struct {
+	Type      WheelType
+	Direction WheelDirection
+	// Rotation is negative when scrolling up or left
+	Rotation int32
+	// Amount is the number of units scrolled per rotation
+	Amount uint16
+	X, Y   int16
+	Clicks uint16
+}
+
+// Wheel returns the wheel fields of a MouseWheel event,
+// ok is false for other kinds
+func (e Event) Wheel() (w WheelEvent, ok bool) {
+	if e.Kind != MouseWheel {
+		return WheelEvent{}, false
+	}
+
+	return WheelEvent{
+		Type:      e.WheelType,
+		Direction: WheelDirection(e.Direction),
+		Rotation:  e.Rotation,
+		Amount:    e.Amount,
+		X:         e.X,
+		Y:         e.Y,
+		Clicks:    e.Clicks,
+	}, true
+}
+
+// Delta returns the units scrolled, negative when scrolling up or left
+func (w WheelEvent) Delta() int32 {
+	return w.Rotation * int32(w.Amount)
+}
+
+// wheelButtons are the MouseMap names a MouseWheel binding takes
+var wheelButtons = []string{"wheelUp", "wheelDown", "wheelLeft", "wheelRight"}
+
+// WithWheelStep makes a MouseWheel binding add up the rotation of its
+// events and fire once for every step units, so the small deltas of a
+// trackpad turn into the notches of a wheel. Turning the other way
+// drops what was added up so far.
+func WithWheelStep(step int32) BindOption {
+	return func(b *Binding) {
+		b.step = step
+	}
+}
+
+// wheelButton returns the MouseMap code of the wheel name a MouseWheel
+// event scrolls in, a negative rotation scrolls up or left
+func wheelButton(ev Event) (Code, bool) {
+	switch {
+	case ev.Rotation == 0:
+		return 0, false
+	case WheelDirection(ev.Direction) == WheelHorizontal && ev.Rotation < 0:
+		return Code(MouseMap["wheelLeft"]), true
+	case WheelDirection(ev.Direction) == WheelHorizontal:
+		return Code(MouseMap["wheelRight"]), true
+	case ev.Rotation < 0:
+		return Code(MouseMap["wheelUp"]), true
+	}
+
+	return Code(MouseMap["wheelDown"]), true
+}
+
+// isWheelButton reports whether code is one of wheelButtons
+func isWheelButton(code Code) bool {
+	for _, name := range wheelButtons {
+		if code == Code(MouseMap[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+// oppositeWheel returns the wheel button scrolling the other way
+func oppositeWheel(code Code) Code {
+	switch code {
+	case Code(MouseMap["wheelUp"]):
+		return Code(MouseMap["wheelDown"])
+	case Code(MouseMap["wheelDown"]):
+		return Code(MouseMap["wheelUp"])
+	case Code(MouseMap["wheelLeft"]):
+		return Code(MouseMap["wheelRight"])
+	}
+	return Code(MouseMap["wheelLeft"])
+}
+
+// advanceWheel returns the callbacks to call for a MouseWheel event,
+// the caller must hold stateMu
+func (h *Hook) advanceWheel(ev Event) (cbs []func(Event)) {
+	button, ok := wheelButton(ev)
+	if !ok {
+		return
+	}
+
+	for b := range h.index[MouseWheel][oppositeWheel(button)] {
+		b.acc = 0
+	}
+
+	rotation := ev.Rotation
+	if rotation < 0 {
+		rotation = -rotation
+	}
+
+	for b := range h.index[MouseWheel][button] {
+		if !allPressed(h.pressed, b.codes) || !b.modifiersMatch(Modifiers(ev.Mask)) {
+			continue
+		}
+
+		if b.step <= 0 {
+			h.log("calling %v %v\n", b.codes, b.buttons)
+			cbs = append(cbs, b.cb)
+			continue
+		}
+
+		b.acc += rotation
+		for ; b.acc >= b.step; b.acc -= b.step {
+			h.log("calling %v %v\n", b.codes, b.buttons)
+			cbs = append(cbs, b.cb)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,85 @@
+package hook
+
+import "testing"
+
+// scroll returns a MouseWheel event
+func scroll(direction WheelDirection, rotation int32, mask Modifiers) Event {
+	return Event{Kind: MouseWheel, Direction: uint8(direction), Rotation: rotation, Amount: 3, Mask: uint16(mask)}
+}
+
+func TestWheel(t *testing.T) {
+	h := New()
+	zoom := make(chan bool, 8)
+	right := make(chan bool, 8)
+	if _, err := h.Register(MouseWheel, []string{"ctrl", "wheelUp"}, func(e Event) {
+		zoom <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.RegisterHotkey(MouseWheel, "wheelright", func(e Event) {
+		right <- true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	// without ctrl
+	ch <- scroll(WheelVertical, WheelUp, 0)
+	ch <- Event{Rawcode: Keycode["ctrl"], Kind: KeyDown}
+	ch <- scroll(WheelVertical, WheelUp, ModCtrlLeft)
+	ch <- scroll(WheelVertical, WheelUp, ModCtrlLeft)
+	// wrong direction
+	ch <- scroll(WheelVertical, WheelDown, ModCtrlLeft)
+	ch <- scroll(WheelHorizontal, WheelUp, ModCtrlLeft)
+	ch <- scroll(WheelHorizontal, WheelDown, ModCtrlLeft)
+	close(ch)
+	<-done
+
+	if len(zoom) != 2 {
+		t.Fatal("Expected 2 ctrl+wheelUp, got", len(zoom))
+	}
+	if len(right) != 1 {
+		t.Fatal("Expected 1 wheelRight, got", len(right))
+	}
+}
+
+func TestWheelStep(t *testing.T) {
+	h := New()
+	notches := make(chan bool, 8)
+	if _, err := h.Register(MouseWheel, []string{"wheelDown"}, func(e Event) {
+		notches <- true
+	}, WithWheelStep(3)); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Event)
+	done := h.Process(ch)
+
+	ch <- scroll(WheelVertical, 1, 0)
+	ch <- scroll(WheelVertical, 1, 0)
+	// turning back drops the first two
+	ch <- scroll(WheelVertical, -1, 0)
+	ch <- scroll(WheelVertical, 2, 0)
+	ch <- scroll(WheelVertical, 5, 0)
+	close(ch)
+	<-done
+
+	// 7 units make two notches of 3
+	if len(notches) != 2 {
+		t.Fatal("Expected 2 notches, got", len(notches))
+	}
+}
+
+func TestWheelRegisterErrors(t *testing.T) {
+	h := New()
+	for _, cmds := range [][]string{
+		{"ctrl", "mleft"},
+		{"wheelUp", "wheelDown"},
+	} {
+		if _, err := h.Register(MouseWheel, cmds, func(e Event) {}); err == nil {
+			t.Error("Expected an error for", cmds)
+		}
+	}
+}
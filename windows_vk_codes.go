@@ -94,9 +94,13 @@ var WindowsVKCodes = map[string]uint16{
 var WindowsVKCodeToName = make(map[uint16]string)
 
 func init() {
-	// Build reverse mapping
-	for name, code := range WindowsVKCodes {
-		WindowsVKCodeToName[code] = name
+	// Build reverse mapping, the first name in sorted order
+	// wins for the codes that have several
+	for _, name := range keyNames() {
+		code, ok := WindowsVKCodes[name]
+		if _, seen := WindowsVKCodeToName[code]; ok && !seen {
+			WindowsVKCodeToName[code] = name
+		}
 	}
 }
 
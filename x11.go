@@ -0,0 +1,747 @@
+// Copyright 2016 The go-vgo Project Developers. See the COPYRIGHT
+// file at the top-level directory of this distribution and at
+// https://github.com/go-vgo/robotgo/blob/master/LICENSE
+//
+// Licensed under the Apache License, Version 2.0 <LICENSE-APACHE or
+// http://www.apache.org/licenses/LICENSE-2.0> or the MIT license
+// <LICENSE-MIT or http://opensource.org/licenses/MIT>, at your
+// option. This file may not be copied, modified, or distributed
+// except according to those terms.
+
+package hook
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// X11 protocol constants, see the X Window System Protocol
+// and the Record Extension Protocol Specification
+const (
+	x11KeyPress      = 2
+	x11KeyRelease    = 3
+	x11ButtonPress   = 4
+	x11ButtonRelease = 5
+	x11MotionNotify  = 6
+
+	x11QueryExtension = 98
+	x11GetInputFocus  = 43
+
+	recordQueryVersion  = 0
+	recordCreateContext = 1
+	recordEnableContext = 5
+
+	recordAllClients  = 3
+	recordFromServer  = 0
+	recordFromClient  = 1
+	recordStartOfData = 4
+	recordEndOfData   = 5
+
+	xtestFakeInput = 2
+)
+
+// x11ClickTime is the longest pause in server milliseconds between two
+// presses of a button counted as a multi click, the default of the native hook
+const x11ClickTime = 200
+
+// x11Button is the libuiohook button of an X button
+// and the modifier the native hook sets for it
+type x11Button struct {
+	button uint16
+	mask   Modifiers
+}
+
+// x11Buttons maps the X buttons as the native hook does, which marks
+// both extra buttons with ModButton5; the others are reported as 0
+var x11Buttons = map[uint8]x11Button{
+	1: {1, ModButton1},
+	2: {2, ModButton2},
+	3: {3, ModButton3},
+	8: {4, ModButton5},
+	9: {5, ModButton5},
+}
+
+// x11ModifierKeys maps the VC_* codes of the modifier keys to their Modifiers
+var x11ModifierKeys = map[uint16]Modifiers{
+	0x002A: ModShiftLeft,
+	0x0036: ModShiftRight,
+	0x001D: ModCtrlLeft,
+	0x0E1D: ModCtrlRight,
+	0x0038: ModAltLeft,
+	0x0E38: ModAltRight,
+	0x0E5B: ModMetaLeft,
+	0x0E5C: ModMetaRight,
+}
+
+// x11LockKeys maps the lock keysyms to their Modifiers
+var x11LockKeys = map[uint32]Modifiers{
+	keysymCapsLock:   ModCapsLock,
+	keysymNumLock:    ModNumLock,
+	keysymScrollLock: ModScrollLock,
+}
+
+// NewX11Source returns a Source recording the key and button events of
+// an X server with the RECORD extension, speaking the X11 protocol
+// itself so it needs neither cgo nor the X11 libraries
+//
+// display has the form of $DISPLAY, which is used if it is empty.
+// The events are the ones of the native X11 hook: Rawcode is the keysym,
+// Keycode the VC_* code and Keychar follows the keyboard mapping of the
+// server, read when the source starts.
+func NewX11Source(display string) Source {
+	return &x11Source{display: display}
+}
+
+type x11Source struct {
+	display string
+
+	mu      sync.Mutex
+	ev      chan Event
+	done    chan struct{}
+	ctrl    *x11Conn
+	data    *x11Conn
+	reading sync.WaitGroup
+}
+
+func (s *x11Source) Start(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ev != nil {
+		return nil
+	}
+
+	display := s.display
+	if display == "" {
+		display = os.Getenv("DISPLAY")
+	}
+
+	ctrl, err := dialX11(ctx, display)
+	if err != nil {
+		return err
+	}
+	data, err := dialX11(ctx, display)
+	if err != nil {
+		ctrl.Close()
+		return err
+	}
+
+	keys, err := ctrl.keymap()
+	if err != nil {
+		ctrl.Close()
+		data.Close()
+		return err
+	}
+	// without XTEST nothing is injected that could be told apart
+	xtest, err := ctrl.extension("XTEST")
+	if err != nil {
+		ctrl.Close()
+		data.Close()
+		return err
+	}
+	if err := startRecord(ctrl, data, xtest); err != nil {
+		ctrl.Close()
+		data.Close()
+		return err
+	}
+
+	s.ev = make(chan Event, evdevBuffer)
+	s.done = make(chan struct{})
+	s.ctrl, s.data = ctrl, data
+	s.ev <- Event{Kind: HookEnabled}
+	foreignSourceStarted("X11", 1)
+
+	s.reading.Add(1)
+	go s.read(data, &x11Input{keys: keys, xtest: xtest}, s.ev, s.done)
+	return nil
+}
+
+// startRecord creates a context recording the device events and the
+// XTEST FakeInput requests, if xtest is its opcode, on ctrl and enables
+// it on data, which is busy with it from then on
+func startRecord(ctrl, data *x11Conn, xtest uint8) error {
+	op, err := ctrl.extension("RECORD")
+	if err != nil {
+		return err
+	}
+	if op == 0 {
+		return ErrXRecordMissing
+	}
+
+	version := x11Request(op, recordQueryVersion,
+		binary.LittleEndian.AppendUint32(nil, 1|13<<16))
+	if _, err := ctrl.roundTrip(version); err != nil {
+		return fmt.Errorf("%w: %v", ErrXRecordContext, err)
+	}
+
+	// a single range with the device events and the injecting requests
+	rc := ctrl.idBase | 1
+	body := binary.LittleEndian.AppendUint32(nil, rc)
+	body = append(body, 0, 0, 0, 0)
+	body = binary.LittleEndian.AppendUint32(body, 1)
+	body = binary.LittleEndian.AppendUint32(body, 1)
+	body = binary.LittleEndian.AppendUint32(body, recordAllClients)
+	rng := make([]byte, 24)
+	rng[18], rng[19] = x11KeyPress, x11MotionNotify
+	if xtest != 0 {
+		rng[4], rng[5] = xtest, xtest
+		binary.LittleEndian.PutUint16(rng[6:], xtestFakeInput)
+		binary.LittleEndian.PutUint16(rng[8:], xtestFakeInput)
+	}
+	body = append(body, rng...)
+
+	if err := ctrl.send(x11Request(op, recordCreateContext, body)); err != nil {
+		return err
+	}
+	// the context has no reply, a round trip reports its errors
+	if _, err := ctrl.roundTrip(x11Request(x11GetInputFocus, 0, nil)); err != nil {
+		return fmt.Errorf("%w: %v", ErrXRecordContext, err)
+	}
+
+	enable := x11Request(op, recordEnableContext, binary.LittleEndian.AppendUint32(nil, rc))
+	if err := data.send(enable); err != nil {
+		return err
+	}
+	for {
+		header, _, err := data.recordReply()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrXRecordContext, err)
+		}
+		if header[1] == recordStartOfData {
+			return nil
+		}
+	}
+}
+
+// read turns the recorded data into events until data is closed
+func (s *x11Source) read(data *x11Conn, in *x11Input, ev chan<- Event, done <-chan struct{}) {
+	defer s.reading.Done()
+
+	clock := &calibratedClock{unit: time.Millisecond}
+	out := []Event{}
+	for {
+		header, body, err := data.recordReply()
+		if err != nil || header[1] == recordEndOfData {
+			return
+		}
+		order := binary.ByteOrder(binary.LittleEndian)
+		if header[9] != 0 {
+			// the client swapped flag
+			order = binary.BigEndian
+		}
+
+		if header[1] == recordFromClient {
+			for len(body) >= 4 {
+				n := 4 * int(order.Uint16(body[2:]))
+				if n == 0 || n > len(body) {
+					break
+				}
+				in.request(body[:n])
+				body = body[n:]
+			}
+			continue
+		}
+		if header[1] != recordFromServer {
+			continue
+		}
+		for ; len(body) >= 32; body = body[32:] {
+			out = in.decode(order, body[:32], out[:0])
+			when := clock.at(uint64(order.Uint32(body[4:])), time.Now())
+			for _, e := range out {
+				e.When = when
+				select {
+				case ev <- e:
+				case <-done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// x11Input follows the keyboard and the mouse through the recorded
+// device events, the way hook/x11/hook_c.h does
+type x11Input struct {
+	keys *x11Keymap
+	mask Modifiers
+	// locked holds the lock keys that were locked when pressed,
+	// their release unlocks them
+	locked Modifiers
+
+	dragged    bool
+	clickTime  uint32
+	clickCount uint16
+	click      uint16
+
+	// xtest is the opcode of the XTEST extension and fake the last
+	// FakeInput request, the server handles it right away so the
+	// next device event matching it is the injected one
+	xtest uint8
+	fake  struct {
+		pending      bool
+		kind, detail uint8
+	}
+}
+
+// request notes a recorded XTEST FakeInput request
+func (in *x11Input) request(b []byte) {
+	if in.xtest == 0 || len(b) < 6 || b[0] != in.xtest || b[1] != xtestFakeInput {
+		return
+	}
+	in.fake.pending, in.fake.kind, in.fake.detail = true, b[4], b[5]
+}
+
+// decode appends the events of a recorded device event to out
+func (in *x11Input) decode(order binary.ByteOrder, b []byte, out []Event) []Event {
+	kind, detail := b[0]&0x7f, b[1]
+	at := order.Uint32(b[4:])
+	state := order.Uint16(b[28:])
+	e := Event{NativeTime: uint64(at)}
+	e.Synthetic = in.fake.pending && kind == in.fake.kind &&
+		(kind == x11MotionNotify || detail == in.fake.detail)
+	in.fake.pending = false
+	mouse := e
+	mouse.X, mouse.Y = int16(order.Uint16(b[20:])), int16(order.Uint16(b[22:]))
+
+	in.mask &^= ModCapsLock | ModNumLock
+	if state&x11LockMask != 0 {
+		in.mask |= ModCapsLock
+	}
+	if state&in.keys.numLock != 0 {
+		in.mask |= ModNumLock
+	}
+
+	switch kind {
+	case x11KeyPress, x11KeyRelease:
+		press := kind == x11KeyPress
+		sym := in.keys.keysym(detail, state)
+		code := x11Keycodes[detail]
+
+		if m, ok := x11ModifierKeys[code]; ok && press {
+			in.mask |= m
+		} else if ok {
+			in.mask &^= m
+		}
+		if m, ok := x11LockKeys[sym]; ok && press {
+			in.locked = in.locked&^m | in.mask&m
+			in.mask |= m
+		} else if ok && in.locked&m != 0 {
+			in.mask &^= m
+		}
+
+		if in.mask&ModNumLock == 0 {
+			switch code {
+			case 0x0053, 0x004F, 0x0050, 0x0051, 0x004B, 0x004C, 0x004D, 0x0047, 0x0048, 0x0052, 0x0049:
+				// VC_KP_SEPARATOR and VC_KP_0 to VC_KP_9 without num lock
+				code |= 0xEE00
+			}
+		}
+
+		e.Keycode, e.Rawcode, e.Keychar = code, uint16(sym), CharUndefined
+		e.Mask = uint16(in.mask)
+		if !press {
+			e.Kind = KeyUp
+			return append(out, e)
+		}
+		e.Kind = KeyDown
+		out = append(out, e)
+
+		// every press is typed, keys without a character type 0
+		c := keysymChar(sym, state&x11ControlMask != 0)
+		if c >= 0xd800 && c <= 0xdfff {
+			return out
+		}
+		e.Kind, e.Keycode = KeyHold, 0
+		for _, r := range utf16.Encode([]rune{c}) {
+			e.Keychar = rune(r)
+			out = append(out, e)
+		}
+		return out
+
+	case x11ButtonPress:
+		if detail >= 4 && detail <= 7 {
+			// the wheel is pressed once per notch
+			in.clickCount, in.click = 1, 0
+			mouse.Kind, mouse.Clicks = MouseWheel, 1
+			mouse.WheelType, mouse.Amount = WheelUnitScroll, 3
+			mouse.Rotation = 1
+			if detail == 4 || detail == 6 {
+				mouse.Rotation = -1
+			}
+			mouse.Direction = uint8(WheelVertical)
+			if detail >= 6 {
+				mouse.Direction = uint8(WheelHorizontal)
+			}
+			mouse.Mask = uint16(in.mask)
+			return append(out, mouse)
+		}
+
+		b := x11Buttons[detail]
+		in.mask |= b.mask
+		if b.button == in.click && at-in.clickTime <= x11ClickTime {
+			if in.clickCount < math.MaxUint16 {
+				in.clickCount++
+			}
+		} else {
+			in.clickCount, in.click = 1, b.button
+		}
+		in.clickTime = at
+
+		mouse.Kind, mouse.Button, mouse.Clicks = MouseDown, b.button, in.clickCount
+		mouse.Mask = uint16(in.mask)
+		return append(out, mouse)
+
+	case x11ButtonRelease:
+		// only the vertical wheel has no release,
+		// the horizontal one is released as button 0
+		if detail == 4 || detail == 5 {
+			return out
+		}
+
+		b := x11Buttons[detail]
+		in.mask &^= b.mask
+		mouse.Button, mouse.Clicks = b.button, in.clickCount
+		mouse.Mask = uint16(in.mask)
+		mouse.Kind = MouseHold
+		out = append(out, mouse)
+		if !in.dragged {
+			mouse.Kind = MouseUp
+			out = append(out, mouse)
+		}
+
+		if b.button == in.click && at-in.clickTime > x11ClickTime {
+			in.clickCount = 0
+		}
+		return out
+
+	case x11MotionNotify:
+		if in.clickCount != 0 && at-in.clickTime > x11ClickTime {
+			in.clickCount = 0
+		}
+
+		in.dragged = in.mask&ModButtons != 0
+		mouse.Kind = MouseMove
+		if in.dragged {
+			mouse.Kind = MouseDrag
+		}
+		mouse.Clicks = in.clickCount
+		mouse.Mask = uint16(in.mask)
+		return append(out, mouse)
+	}
+
+	return out
+}
+
+func (s *x11Source) Events() <-chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ev
+}
+
+func (s *x11Source) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ev == nil {
+		return
+	}
+
+	// the server frees the context with the connection,
+	// closing data ends the reader
+	close(s.done)
+	s.data.Close()
+	s.ctrl.Close()
+	s.reading.Wait()
+	close(s.ev)
+	s.ev, s.done, s.ctrl, s.data = nil, nil, nil, nil
+	foreignSourceStarted("X11", -1)
+}
+
+// x11Conn is a client connection speaking the little endian protocol
+type x11Conn struct {
+	net.Conn
+	r      *bufio.Reader
+	idBase uint32
+
+	minKeycode, maxKeycode uint8
+}
+
+// dialX11 connects to display and completes the connection setup
+func dialX11(ctx context.Context, display string) (*x11Conn, error) {
+	host, number, err := parseDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	var conn net.Conn
+	if host == "" || host == "unix" {
+		conn, err = dialer.DialContext(ctx, "unix", "/tmp/.X11-unix/X"+number)
+	} else {
+		port, _ := strconv.Atoi(number)
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(6000+port)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDisplayUnavailable, err)
+	}
+
+	c := &x11Conn{Conn: conn, r: bufio.NewReader(conn)}
+	if err := c.setup(xauth(host, number, conn.RemoteAddr())); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %v", ErrDisplayUnavailable, err)
+	}
+	return c, nil
+}
+
+// parseDisplay splits a display like "host:0.0" into host and number
+func parseDisplay(display string) (host, number string, err error) {
+	i := strings.LastIndex(display, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("%w: invalid display %q", ErrDisplayUnavailable, display)
+	}
+
+	host, number = display[:i], display[i+1:]
+	if j := strings.Index(number, "."); j >= 0 {
+		number = number[:j]
+	}
+	if _, err := strconv.Atoi(number); err != nil {
+		return "", "", fmt.Errorf("%w: invalid display %q", ErrDisplayUnavailable, display)
+	}
+	return host, number, nil
+}
+
+func (c *x11Conn) setup(authName string, authData []byte) error {
+	req := []byte{'l', 0}
+	req = binary.LittleEndian.AppendUint16(req, 11)
+	req = binary.LittleEndian.AppendUint16(req, 0)
+	req = binary.LittleEndian.AppendUint16(req, uint16(len(authName)))
+	req = binary.LittleEndian.AppendUint16(req, uint16(len(authData)))
+	req = append(req, 0, 0)
+	req = append(req, pad4([]byte(authName))...)
+	req = append(req, pad4(authData)...)
+	if err := c.send(req); err != nil {
+		return err
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return err
+	}
+	info := make([]byte, 4*int(binary.LittleEndian.Uint16(header[6:])))
+	if _, err := io.ReadFull(c.r, info); err != nil {
+		return err
+	}
+
+	switch header[0] {
+	case 1:
+	case 0:
+		return fmt.Errorf("connection refused: %s", info[:min(int(header[1]), len(info))])
+	default:
+		return fmt.Errorf("authentication required: %s", strings.TrimRight(string(info), "\x00"))
+	}
+	if len(info) < 28 {
+		return errors.New("short connection setup")
+	}
+
+	c.idBase = binary.LittleEndian.Uint32(info[4:])
+	c.minKeycode, c.maxKeycode = info[26], info[27]
+	return nil
+}
+
+func (c *x11Conn) send(req []byte) error {
+	_, err := c.Write(req)
+	return err
+}
+
+// extension returns the major opcode of an extension, 0 if it is missing
+func (c *x11Conn) extension(name string) (uint8, error) {
+	body := binary.LittleEndian.AppendUint16(nil, uint16(len(name)))
+	body = append(body, 0, 0)
+	body = append(body, pad4([]byte(name))...)
+
+	reply, err := c.roundTrip(x11Request(x11QueryExtension, 0, body))
+	if err != nil {
+		return 0, err
+	}
+	if reply[8] == 0 {
+		return 0, nil
+	}
+	return reply[9], nil
+}
+
+// roundTrip sends a request and returns its reply
+func (c *x11Conn) roundTrip(req []byte) ([]byte, error) {
+	if err := c.send(req); err != nil {
+		return nil, err
+	}
+
+	for {
+		packet, err := c.packet()
+		if err != nil {
+			return nil, err
+		}
+		switch packet[0] {
+		case 0:
+			return nil, fmt.Errorf("X error %d for request %d.%d", packet[1], packet[10], binary.LittleEndian.Uint16(packet[8:]))
+		case 1:
+			return packet, nil
+		}
+		// an event
+	}
+}
+
+// packet reads an error, an event or a reply with its extra data
+func (c *x11Conn) packet() ([]byte, error) {
+	packet := make([]byte, 32)
+	if _, err := io.ReadFull(c.r, packet); err != nil {
+		return nil, err
+	}
+	if packet[0] != 1 {
+		return packet, nil
+	}
+
+	extra := 4 * int(binary.LittleEndian.Uint32(packet[4:]))
+	packet = append(packet, make([]byte, extra)...)
+	if _, err := io.ReadFull(c.r, packet[32:]); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+// recordReply reads a reply of an enabled RECORD context,
+// the header holds the category in its second byte
+func (c *x11Conn) recordReply() (header, data []byte, err error) {
+	packet, err := c.packet()
+	if err != nil {
+		return nil, nil, err
+	}
+	if packet[0] == 0 {
+		return nil, nil, fmt.Errorf("X error %d", packet[1])
+	}
+	return packet[:32], packet[32:], nil
+}
+
+// x11Request builds a request, data is the second byte of the header
+func x11Request(opcode, data uint8, body []byte) []byte {
+	body = pad4(body)
+	req := []byte{opcode, data}
+	req = binary.LittleEndian.AppendUint16(req, uint16(1+len(body)/4))
+	return append(req, body...)
+}
+
+// pad4 pads b with zeros to a multiple of four bytes
+func pad4(b []byte) []byte {
+	return append(b, make([]byte, (4-len(b)%4)%4)...)
+}
+
+// xauth returns the MIT-MAGIC-COOKIE-1 of a display connected to
+// remote from the Xauthority file, or nothing if there is none
+func xauth(host, number string, remote net.Addr) (string, []byte) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+
+	var ip net.IP
+	if addr, ok := remote.(*net.TCPAddr); ok {
+		ip = addr.IP
+	}
+	// like Xlib, the displays of this machine use its hostname
+	if host == "" || host == "unix" || ip.IsLoopback() {
+		host, _ = os.Hostname()
+	}
+	return findXauth(b, host, ip, number)
+}
+
+// Xauthority address families
+const (
+	xauthInternet  = 0
+	xauthInternet6 = 6
+	xauthLocal     = 256
+	xauthWild      = 65535
+)
+
+// findXauth looks up the cookie of a display in an Xauthority file,
+// a list of entries of big endian counted strings. Local entries
+// match host and Internet ones ip, the address of a TCP display.
+func findXauth(b []byte, host string, ip net.IP, number string) (string, []byte) {
+	field := func() ([]byte, bool) {
+		if len(b) < 2 {
+			return nil, false
+		}
+		n := int(binary.BigEndian.Uint16(b))
+		if len(b) < 2+n {
+			return nil, false
+		}
+		f := b[2 : 2+n]
+		b = b[2+n:]
+		return f, true
+	}
+
+	for len(b) >= 2 {
+		family := binary.BigEndian.Uint16(b)
+		b = b[2:]
+		addr, ok1 := field()
+		num, ok2 := field()
+		name, ok3 := field()
+		data, ok4 := field()
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return "", nil
+		}
+
+		if !xauthMatch(family, addr, host, ip) {
+			continue
+		}
+		if len(num) != 0 && string(num) != number {
+			continue
+		}
+		if string(name) == "MIT-MAGIC-COOKIE-1" {
+			return string(name), data
+		}
+	}
+	return "", nil
+}
+
+// xauthMatch reports whether the address of an Xauthority entry
+// is the one of a display on host or at ip
+func xauthMatch(family uint16, addr []byte, host string, ip net.IP) bool {
+	switch family {
+	case xauthWild:
+		return true
+	case xauthLocal:
+		return host != "" && string(addr) == host
+	case xauthInternet:
+		return ip.To4() != nil && bytes.Equal(addr, ip.To4())
+	case xauthInternet6:
+		return ip.To4() == nil && ip.To16() != nil && bytes.Equal(addr, ip.To16())
+	}
+	return false
+}
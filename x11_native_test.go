@@ -0,0 +1,157 @@
+//go:build linux && cgo && !nocgo
+
+package hook
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// startXvfb runs an Xvfb server for the test
+func startXvfb(t *testing.T) string {
+	t.Helper()
+
+	path, err := exec.LookPath("Xvfb")
+	if err != nil {
+		t.Skip("Xvfb is not installed")
+	}
+
+	for n := 90; n < 110; n++ {
+		socket := "/tmp/.X11-unix/X" + strconv.Itoa(n)
+		if _, err := os.Stat(socket); err == nil {
+			continue
+		}
+
+		display := ":" + strconv.Itoa(n)
+		cmd := exec.Command(path, display, "-nolisten", "tcp", "+extension", "RECORD", "+extension", "XTEST")
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		})
+
+		for i := 0; i < 100; i++ {
+			if _, err := os.Stat(socket); err == nil {
+				return display
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatal("Timeout waiting for Xvfb")
+	}
+
+	t.Skip("no free display")
+	return ""
+}
+
+// fakeInput injects an event with the XTEST extension
+func fakeInput(t *testing.T, c *x11Conn, op, kind, detail uint8, x, y int16) {
+	t.Helper()
+
+	body := []byte{kind, detail, 0, 0}
+	body = append(body, make([]byte, 16)...)
+	body = binary.LittleEndian.AppendUint16(body, uint16(x))
+	body = binary.LittleEndian.AppendUint16(body, uint16(y))
+	body = append(body, make([]byte, 8)...)
+	if err := c.send(x11Request(op, xtestFakeInput, body)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.roundTrip(x11Request(x11GetInputFocus, 0, nil)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sourceEvents reads n events of src, leaving out HookEnabled
+func sourceEvents(t *testing.T, src Source, n int) []Event {
+	t.Helper()
+
+	var got []Event
+	for len(got) < n {
+		select {
+		case <-time.After(TIMEOUT):
+			t.Fatalf("Timeout after %d events: %v", len(got), got)
+		case e := <-src.Events():
+			if e.Kind == HookEnabled {
+				continue
+			}
+			e.When = time.Time{}
+			got = append(got, e)
+		}
+	}
+	return got
+}
+
+func TestX11SourceMatchesNative(t *testing.T) {
+	display := startXvfb(t)
+	t.Setenv("DISPLAY", display)
+
+	native := NewNativeSource()
+	if err := native.Start(context.Background()); err != nil {
+		t.Skip("no native X11 hook:", err)
+	}
+	defer native.Stop()
+
+	src := NewX11Source(display)
+	if err := src.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Stop()
+
+	c, err := dialX11(context.Background(), display)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	op, err := c.extension("XTEST")
+	if err != nil || op == 0 {
+		t.Skip("no XTEST extension:", err)
+	}
+	keys, err := c.keymap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keycode := func(sym uint32) uint8 {
+		for k := int(keys.min); k < 256; k++ {
+			if s := keys.lookup(uint8(k)); len(s) > 0 && s[0] == sym {
+				return uint8(k)
+			}
+		}
+		t.Fatalf("No keycode for keysym %#x", sym)
+		return 0
+	}
+
+	ctrl, shift, a, one := keycode(0xffe3), keycode(0xffe1), keycode('a'), keycode('1')
+	fakeInput(t, c, op, x11KeyPress, ctrl, 0, 0)
+	fakeInput(t, c, op, x11KeyPress, a, 0, 0)
+	fakeInput(t, c, op, x11KeyRelease, a, 0, 0)
+	fakeInput(t, c, op, x11KeyRelease, ctrl, 0, 0)
+	fakeInput(t, c, op, x11KeyPress, shift, 0, 0)
+	fakeInput(t, c, op, x11KeyPress, one, 0, 0)
+	fakeInput(t, c, op, x11KeyRelease, one, 0, 0)
+	fakeInput(t, c, op, x11KeyRelease, shift, 0, 0)
+	fakeInput(t, c, op, x11MotionNotify, 0, 12, 34)
+	fakeInput(t, c, op, x11ButtonPress, 1, 0, 0)
+	fakeInput(t, c, op, x11MotionNotify, 0, 20, 40)
+	fakeInput(t, c, op, x11ButtonRelease, 1, 0, 0)
+	fakeInput(t, c, op, x11ButtonPress, 3, 0, 0)
+	fakeInput(t, c, op, x11ButtonRelease, 3, 0, 0)
+	fakeInput(t, c, op, x11ButtonPress, 4, 0, 0)
+	fakeInput(t, c, op, x11ButtonRelease, 4, 0, 0)
+
+	// 8 keys with 4 typed, 2 moves, 2 presses, 2 releases,
+	// 1 click and 1 wheel
+	const n = 20
+	want := sourceEvents(t, native, n)
+	got := sourceEvents(t, src, n)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Event %d: got %+v, the native hook %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package hook
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDisplay(t *testing.T) {
+	tests := []struct {
+		display, host, number string
+	}{
+		{":0", "", "0"},
+		{":1.0", "", "1"},
+		{"unix:2", "unix", "2"},
+		{"localhost:10.1", "localhost", "10"},
+		{"[::1]:3", "[::1]", "3"},
+	}
+	for _, tt := range tests {
+		host, number, err := parseDisplay(tt.display)
+		if err != nil || host != tt.host || number != tt.number {
+			t.Errorf("%q: got %q, %q, %v", tt.display, host, number, err)
+		}
+	}
+
+	for _, display := range []string{"", "host", ":x"} {
+		if _, _, err := parseDisplay(display); !errors.Is(err, ErrDisplayUnavailable) {
+			t.Errorf("%q: expected ErrDisplayUnavailable, got %v", display, err)
+		}
+	}
+}
+
+// xauthEntry encodes an entry of an Xauthority file
+func xauthEntry(family uint16, fields ...string) []byte {
+	b := binary.BigEndian.AppendUint16(nil, family)
+	for _, f := range fields {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(f)))
+		b = append(b, f...)
+	}
+	return b
+}
+
+func TestFindXauth(t *testing.T) {
+	file := append(xauthEntry(xauthLocal, "other", "0", "MIT-MAGIC-COOKIE-1", "wrong"),
+		xauthEntry(xauthLocal, "box", "1", "MIT-MAGIC-COOKIE-1", "one")...)
+	file = append(file, xauthEntry(xauthLocal, "box", "0", "MIT-MAGIC-COOKIE-1", "zero")...)
+
+	if name, data := findXauth(file, "box", nil, "0"); name != "MIT-MAGIC-COOKIE-1" || string(data) != "zero" {
+		t.Fatalf("Expected the cookie of box:0, got %q %q", name, data)
+	}
+	if name, _ := findXauth(file, "box", nil, "2"); name != "" {
+		t.Fatal("Expected no cookie for box:2, got", name)
+	}
+
+	wild := xauthEntry(xauthWild, "", "", "MIT-MAGIC-COOKIE-1", "any")
+	if _, data := findXauth(wild, "box", nil, "5"); string(data) != "any" {
+		t.Fatal("Expected the wildcard cookie, got", data)
+	}
+	if name, _ := findXauth(file[:7], "box", nil, "0"); name != "" {
+		t.Fatal("Expected nothing from a truncated file, got", name)
+	}
+}
+
+func TestFindXauthInternet(t *testing.T) {
+	file := append(xauthEntry(xauthInternet, "\x0a\x00\x00\x05", "0", "MIT-MAGIC-COOKIE-1", "v4"),
+		xauthEntry(xauthInternet6, string(net.ParseIP("fd00::5")), "0", "MIT-MAGIC-COOKIE-1", "v6")...)
+
+	tests := []struct {
+		ip, want string
+	}{
+		{"10.0.0.5", "v4"},
+		{"fd00::5", "v6"},
+		{"10.0.0.6", ""},
+		{"fd00::6", ""},
+	}
+	for _, tt := range tests {
+		if _, data := findXauth(file, "", net.ParseIP(tt.ip), "0"); string(data) != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.ip, tt.want, data)
+		}
+	}
+	if _, data := findXauth(file, "10.0.0.5", nil, "0"); data != nil {
+		t.Fatal("Expected an Internet entry to need the address, got", data)
+	}
+}
+
+func TestX11SourceXauthInternet(t *testing.T) {
+	x, display := newFakeX11(t)
+
+	// the entry of the TCP display, 127.0.0.1
+	path := filepath.Join(t.TempDir(), "Xauthority")
+	file := xauthEntry(xauthInternet, "\x7f\x00\x00\x01", display[strings.LastIndex(display, ":")+1:],
+		"MIT-MAGIC-COOKIE-1", "cookie")
+	if err := os.WriteFile(path, file, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XAUTHORITY", path)
+
+	src := NewX11Source(display)
+	if err := src.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Stop()
+
+	for range 2 {
+		if auth := <-x.auth; auth != "cookie" {
+			t.Fatalf("Expected the cookie of the Internet entry, got %q", auth)
+		}
+	}
+}
+
+// fakeX11 is a minimal X server with the RECORD and XTEST extensions,
+// it sends recorded once its context is enabled
+type fakeX11 struct {
+	ln       net.Listener
+	recorded []fakeRecord
+	rng      chan []byte
+	// auth gets the authorization data of each connection
+	auth chan string
+}
+
+// fakeRecord is the data of a recorded reply of the given category
+type fakeRecord struct {
+	category uint8
+	data     []byte
+}
+
+const (
+	fakeRecordOpcode = 200
+	fakeXTestOpcode  = 201
+)
+
+// fakeKeysyms is the keyboard mapping of fakeX11, a part of a US layout
+var fakeKeysyms = map[uint8][2]uint32{
+	10: {'1', '!'},
+	37: {0xffe3, 0}, // Control_L
+	38: {'a', 'A'},
+	50: {0xffe1, 0},      // Shift_L
+	66: {0xffe5, 0},      // Caps_Lock
+	77: {0xff7f, 0},      // Num_Lock
+	79: {0xff95, 0xffb7}, // KP_Home, KP_7
+}
+
+// fakeModifiers puts Shift_L on shift, Caps_Lock on lock,
+// Control_L on control and Num_Lock on mod2
+var fakeModifiers = [8]uint8{50, 66, 37, 0, 77, 0, 0, 0}
+
+func newFakeX11(t *testing.T, recorded ...fakeRecord) (*fakeX11, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no local tcp:", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	if port < 6000 {
+		ln.Close()
+		t.Skip("port below the X11 range:", port)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	x := &fakeX11{ln: ln, recorded: recorded, rng: make(chan []byte, 1), auth: make(chan string, 2)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go x.serve(conn)
+		}
+	}()
+
+	return x, fmt.Sprintf("127.0.0.1:%d", port-6000)
+}
+
+func (x *fakeX11) serve(conn net.Conn) {
+	defer conn.Close()
+
+	setup := make([]byte, 12)
+	if _, err := io.ReadFull(conn, setup); err != nil {
+		return
+	}
+	nameLen := len(pad4(make([]byte, binary.LittleEndian.Uint16(setup[6:]))))
+	dataLen := int(binary.LittleEndian.Uint16(setup[8:]))
+	auth := make([]byte, nameLen+len(pad4(make([]byte, dataLen))))
+	if _, err := io.ReadFull(conn, auth); err != nil {
+		return
+	}
+	select {
+	case x.auth <- string(auth[nameLen : nameLen+dataLen]):
+	default:
+	}
+
+	info := make([]byte, 32)
+	binary.LittleEndian.PutUint32(info[4:], 0x00400000)
+	info[26], info[27] = 8, 255
+	reply := []byte{1, 0, 11, 0, 0, 0, 8, 0}
+	conn.Write(append(reply, info...))
+
+	reply32 := func(b1 uint8, fill func(r []byte)) {
+		r := make([]byte, 32)
+		r[0], r[1] = 1, b1
+		if fill != nil {
+			fill(r)
+		}
+		conn.Write(r)
+	}
+
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		body := make([]byte, 4*int(binary.LittleEndian.Uint16(header[2:]))-4)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+
+		switch {
+		case header[0] == x11QueryExtension:
+			reply32(0, func(r []byte) {
+				switch string(body[4 : 4+binary.LittleEndian.Uint16(body)]) {
+				case "RECORD":
+					r[8], r[9] = 1, fakeRecordOpcode
+				case "XTEST":
+					r[8], r[9] = 1, fakeXTestOpcode
+				}
+			})
+		case header[0] == x11GetInputFocus:
+			reply32(0, nil)
+		case header[0] == x11GetKeyboardMapping:
+			syms := []byte{}
+			for k := int(body[0]); k < int(body[0])+int(body[1]); k++ {
+				sym := fakeKeysyms[uint8(k)]
+				syms = binary.LittleEndian.AppendUint32(syms, sym[0])
+				syms = binary.LittleEndian.AppendUint32(syms, sym[1])
+			}
+			reply32(2, func(r []byte) {
+				binary.LittleEndian.PutUint32(r[4:], uint32(len(syms)/4))
+			})
+			conn.Write(syms)
+		case header[0] == x11GetModifierMapping:
+			reply32(1, func(r []byte) {
+				binary.LittleEndian.PutUint32(r[4:], 2)
+			})
+			conn.Write(fakeModifiers[:])
+		case header[0] == fakeRecordOpcode && header[1] == recordQueryVersion:
+			reply32(0, nil)
+		case header[0] == fakeRecordOpcode && header[1] == recordCreateContext:
+			x.rng <- body[20:44]
+		case header[0] == fakeRecordOpcode && header[1] == recordEnableContext:
+			reply32(recordStartOfData, nil)
+			for _, rec := range x.recorded {
+				r := make([]byte, 32)
+				r[0], r[1] = 1, rec.category
+				binary.LittleEndian.PutUint32(r[4:], uint32(len(rec.data)/4))
+				conn.Write(append(r, rec.data...))
+			}
+		}
+	}
+}
+
+// x11Event encodes a core device event
+func x11Event(kind, detail uint8, time uint32, state uint16, x, y int16) []byte {
+	b := make([]byte, 32)
+	b[0], b[1] = kind, detail
+	binary.LittleEndian.PutUint32(b[4:], time)
+	binary.LittleEndian.PutUint16(b[20:], uint16(x))
+	binary.LittleEndian.PutUint16(b[22:], uint16(y))
+	binary.LittleEndian.PutUint16(b[28:], state)
+	return b
+}
+
+func TestX11SourceFakeServer(t *testing.T) {
+	keys := append(x11Event(x11KeyPress, 37, 1000, 0, 0, 0),
+		x11Event(x11KeyPress, 38, 1010, x11ControlMask, 0, 0)...)
+	mouse := append(x11Event(x11MotionNotify, 0, 1020, 0, 30, 40),
+		x11Event(x11ButtonPress, 3, 1030, 0, 30, 40)...)
+	mouse = append(mouse, x11Event(x11ButtonRelease, 3, 1040, 0, 30, 40)...)
+	mouse = append(mouse, x11Event(x11ButtonPress, 4, 1050, 0, 30, 40)...)
+	mouse = append(mouse, x11Event(x11ButtonRelease, 4, 1050, 0, 30, 40)...)
+	x, display := newFakeX11(t, fakeRecord{recordFromServer, keys}, fakeRecord{recordFromServer, mouse})
+
+	src := NewX11Source(display)
+	if err := src.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Stop()
+
+	rng := <-x.rng
+	if rng[18] != x11KeyPress || rng[19] != x11MotionNotify {
+		t.Fatal("Expected the device events to be recorded, got", rng)
+	}
+
+	ctrl := uint16(ModCtrlLeft)
+	want := []Event{
+		{Kind: HookEnabled},
+		{Kind: KeyDown, Keycode: 0x1D, Rawcode: 0xffe3, Keychar: CharUndefined, Mask: ctrl, NativeTime: 1000},
+		{Kind: KeyHold, Rawcode: 0xffe3, Mask: ctrl, NativeTime: 1000},
+		{Kind: KeyDown, Keycode: 0x1E, Rawcode: 'a', Keychar: CharUndefined, Mask: ctrl, NativeTime: 1010},
+		{Kind: KeyHold, Rawcode: 'a', Keychar: 0x01, Mask: ctrl, NativeTime: 1010},
+		{Kind: MouseMove, X: 30, Y: 40, Mask: ctrl, NativeTime: 1020},
+		{Kind: MouseDown, Button: 3, Clicks: 1, X: 30, Y: 40, Mask: ctrl | uint16(ModButton3), NativeTime: 1030},
+		{Kind: MouseHold, Button: 3, Clicks: 1, X: 30, Y: 40, Mask: ctrl, NativeTime: 1040},
+		{Kind: MouseUp, Button: 3, Clicks: 1, X: 30, Y: 40, Mask: ctrl, NativeTime: 1040},
+		{Kind: MouseWheel, Clicks: 1, X: 30, Y: 40, Mask: ctrl, NativeTime: 1050, WheelType: WheelUnitScroll,
+			Amount: 3, Rotation: -1, Direction: uint8(WheelVertical)},
+	}
+	for i, w := range want {
+		var e Event
+		select {
+		case <-time.After(TIMEOUT):
+			t.Fatal("Timeout waiting for event", i)
+		case e = <-src.Events():
+		}
+
+		e.When = time.Time{}
+		if e != w {
+			t.Errorf("Event %d: got %+v, expected %+v", i, e, w)
+		}
+	}
+}
+
+// fakeInputRequest encodes an XTEST FakeInput request
+func fakeInputRequest(kind, detail uint8) []byte {
+	body := []byte{kind, detail, 0, 0}
+	return x11Request(fakeXTestOpcode, xtestFakeInput, append(body, make([]byte, 28)...))
+}
+
+func TestX11SourceSynthetic(t *testing.T) {
+	x, display := newFakeX11(t,
+		fakeRecord{recordFromClient, fakeInputRequest(x11KeyPress, 38)},
+		fakeRecord{recordFromServer, x11Event(x11KeyPress, 38, 1000, 0, 0, 0)},
+		fakeRecord{recordFromServer, x11Event(x11KeyRelease, 38, 1010, 0, 0, 0)},
+		fakeRecord{recordFromClient, fakeInputRequest(x11MotionNotify, 0)},
+		fakeRecord{recordFromServer, x11Event(x11MotionNotify, 0, 1020, 0, 30, 40)},
+	)
+
+	src := NewX11Source(display)
+	if err := src.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Stop()
+
+	rng := <-x.rng
+	if rng[4] != fakeXTestOpcode || rng[5] != fakeXTestOpcode ||
+		binary.LittleEndian.Uint16(rng[6:]) != xtestFakeInput || binary.LittleEndian.Uint16(rng[8:]) != xtestFakeInput {
+		t.Fatal("Expected the FakeInput requests to be recorded, got", rng)
+	}
+
+	// the posted events would not reach the source
+	if err := PostKey("a", true); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatal("Expected ErrBackendUnavailable while the source runs, got", err)
+	}
+
+	want := []struct {
+		kind      Kind
+		synthetic bool
+	}{
+		{HookEnabled, false},
+		{KeyDown, true},
+		{KeyHold, true},
+		{KeyUp, false},
+		{MouseMove, true},
+	}
+	for i, w := range want {
+		var e Event
+		select {
+		case <-time.After(TIMEOUT):
+			t.Fatal("Timeout waiting for event", i)
+		case e = <-src.Events():
+		}
+		if e.Kind != w.kind || e.Synthetic != w.synthetic {
+			t.Errorf("Event %d: got %v with Synthetic %v, expected %v with %v", i, e.Kind, e.Synthetic, w.kind, w.synthetic)
+		}
+	}
+}
+
+func TestX11Keymap(t *testing.T) {
+	k := &x11Keymap{min: 8, per: 2, syms: make([]uint32, 2*248), numLock: 1 << 4}
+	for code, syms := range fakeKeysyms {
+		copy(k.syms[2*(int(code)-8):], syms[:])
+	}
+
+	tests := []struct {
+		keycode uint8
+		state   uint16
+		sym     uint32
+		char    rune
+	}{
+		{38, 0, 'a', 'a'},
+		{38, x11ShiftMask, 'A', 'A'},
+		{38, x11LockMask, 'A', 'A'},
+		{38, x11ShiftMask | x11LockMask, 'a', 'a'},
+		{38, x11ControlMask, 'a', 0x01},
+		{10, x11LockMask, '1', '1'},
+		{10, x11ShiftMask, '!', '!'},
+		{79, 0, 0xff95, 0},
+		{79, 1 << 4, 0xffb7, '7'},
+		{79, 1<<4 | x11ShiftMask, 0xff95, 0},
+		{37, x11ShiftMask, 0xffe3, 0},
+		{200, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		sym := k.keysym(tt.keycode, tt.state)
+		char := keysymChar(sym, tt.state&x11ControlMask != 0)
+		if sym != tt.sym || char != tt.char {
+			t.Errorf("Keycode %d with state %#x: got %#x %q, expected %#x %q",
+				tt.keycode, tt.state, sym, char, tt.sym, tt.char)
+		}
+	}
+}
+
+func TestX11SourceUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no local tcp:", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	display := "127.0.0.1:" + strconv.Itoa(port-6000)
+	if err := NewX11Source(display).Start(context.Background()); !errors.Is(err, ErrDisplayUnavailable) {
+		t.Fatal("Expected ErrDisplayUnavailable, got", err)
+	}
+}